@@ -0,0 +1,101 @@
+// Command export dumps orders from PostgreSQL to a CSV, NDJSON or Parquet
+// file using the same code path as GET /orders/export.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sonni-a/wb-service/internal/config"
+	"github.com/sonni-a/wb-service/internal/db"
+	"github.com/sonni-a/wb-service/internal/export"
+	"github.com/sonni-a/wb-service/internal/logging"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+func main() {
+	formatFlag := flag.String("format", "csv", "output format: csv, ndjson or parquet")
+	outPath := flag.String("out", "", "output file (default: stdout)")
+	customerID := flag.String("customer-id", "", "only export orders of this customer")
+	deliveryService := flag.String("delivery-service", "", "only export orders of this delivery service")
+	fromFlag := flag.String("from", "", "created at or after (RFC3339 or YYYY-MM-DD)")
+	toFlag := flag.String("to", "", "created before (RFC3339 or YYYY-MM-DD)")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		logging.Fatal("Invalid format", "error", err)
+	}
+
+	from, err := export.ParseTime(*fromFlag)
+	if err != nil {
+		logging.Fatal("Invalid -from", "error", err)
+	}
+	to, err := export.ParseTime(*toFlag)
+	if err != nil {
+		logging.Fatal("Invalid -to", "error", err)
+	}
+
+	filter := repository.OrderFilter{
+		CustomerID:      *customerID,
+		DeliveryService: *deliveryService,
+		From:            from,
+		To:              to,
+	}
+
+	if err := run(cfg, format, filter, *outPath); err != nil {
+		logging.Fatal("Export failed", "error", err)
+	}
+}
+
+func run(cfg *config.Config, format export.Format, filter repository.OrderFilter, outPath string) error {
+	keyring, err := cfg.PII.Keyring()
+	if err != nil {
+		return err
+	}
+
+	pool, err := db.NewPool(cfg.Database.URL, cfg.Database.Pool())
+	if err != nil {
+		return fmt.Errorf("connect to DB: %w", err)
+	}
+	defer pool.Close()
+	repo := repository.NewOrderRepository(pool)
+	repo.SetKeyring(keyring)
+
+	var out io.Writer = os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("create output file: %w", err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				slog.Error("Failed to close output file", "error", err)
+			}
+		}()
+		out = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	count, err := export.Run(ctx, repo, filter, format, out)
+	if err != nil {
+		return fmt.Errorf("export failed after %d orders: %w", count, err)
+	}
+
+	slog.Info("Exported orders", "count", count, "format", format)
+	return nil
+}
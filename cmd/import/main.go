@@ -0,0 +1,103 @@
+// Command import backfills orders from an NDJSON or CSV file straight into
+// PostgreSQL using the same code path as POST /orders/import.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/config"
+	"github.com/sonni-a/wb-service/internal/db"
+	"github.com/sonni-a/wb-service/internal/importer"
+	"github.com/sonni-a/wb-service/internal/logging"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+func main() {
+	formatFlag := flag.String("format", "ndjson", "input format: ndjson or csv")
+	inPath := flag.String("in", "", "input file (default: stdin)")
+	batchSize := flag.Int("batch-size", importer.DefaultBatchSize, "orders per insert transaction")
+	reportPath := flag.String("report", "", "write the JSON report to this file (default: stdout)")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+
+	format, err := importer.ParseFormat(*formatFlag)
+	if err != nil {
+		logging.Fatal("Invalid format", "error", err)
+	}
+
+	if err := run(cfg, format, *inPath, *reportPath, *batchSize); err != nil {
+		logging.Fatal("Import failed", "error", err)
+	}
+}
+
+func run(cfg *config.Config, format importer.Format, inPath, reportPath string, batchSize int) error {
+	keyring, err := cfg.PII.Keyring()
+	if err != nil {
+		return err
+	}
+
+	pool, err := db.NewPool(cfg.Database.URL, cfg.Database.Pool())
+	if err != nil {
+		return fmt.Errorf("connect to DB: %w", err)
+	}
+	defer pool.Close()
+	repo := repository.NewOrderRepository(pool)
+	repo.SetKeyring(keyring)
+
+	var in io.Reader = os.Stdin
+	if inPath != "" {
+		f, err := os.Open(inPath)
+		if err != nil {
+			return fmt.Errorf("open input file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	ctx, stop := signal.NotifyContext(audit.WithActor(context.Background(), audit.CurrentUser()), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, runErr := importer.Run(ctx, repo, format, in, batchSize)
+	if report != nil {
+		if err := writeReport(report, reportPath); err != nil {
+			return err
+		}
+		slog.Info("Imported orders",
+			"accepted", report.Accepted, "duplicates", report.Duplicates, "rejected", report.Rejected)
+	}
+	if runErr != nil {
+		return fmt.Errorf("import failed: %w", runErr)
+	}
+
+	return nil
+}
+
+func writeReport(report *importer.Report, path string) error {
+	var out io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("create report file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -1,106 +1,380 @@
 // Package main starts the Demo Order service.
 // The service loads configuration, initializes PostgreSQL, Kafka consumer,
-// builds all dependencies, restores cache from DB, starts HTTP API,
-// and performs graceful shutdown on OS signals.
+// builds all dependencies, restores cache from DB, starts the public HTTP API
+// and the admin listener, and performs graceful shutdown on OS signals.
 
 // @title Demo Order Service API
 // @version 1.0
 // @description Demo service that receives orders from Kafka, stores them in PostgreSQL,
 // @description and exposes HTTP API with in-memory caching.
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT as "Bearer <token>"
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/http/pprof"
+	"os"
+	"time"
 
 	_ "github.com/sonni-a/wb-service/docs"
 
+	"github.com/sonni-a/wb-service/internal/auth"
 	"github.com/sonni-a/wb-service/internal/config"
 	"github.com/sonni-a/wb-service/internal/db"
 	"github.com/sonni-a/wb-service/internal/handlers"
+	"github.com/sonni-a/wb-service/internal/health"
 	"github.com/sonni-a/wb-service/internal/kafka"
+	"github.com/sonni-a/wb-service/internal/logging"
 	"github.com/sonni-a/wb-service/internal/metrics"
+	"github.com/sonni-a/wb-service/internal/migrate"
+	"github.com/sonni-a/wb-service/internal/partition"
+	"github.com/sonni-a/wb-service/internal/ratelimit"
 	"github.com/sonni-a/wb-service/internal/repository"
 	"github.com/sonni-a/wb-service/internal/service"
 	"github.com/sonni-a/wb-service/internal/shutdown"
+	"github.com/sonni-a/wb-service/internal/tlsutil"
+	"github.com/sonni-a/wb-service/internal/tracing"
 	"github.com/sonni-a/wb-service/internal/web"
+	"github.com/sonni-a/wb-service/migrations"
 
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rekey" {
+		os.Exit(runRekey(os.Args[2:]))
+	}
+
+	flags, printConfig := newFlagSet(flag.ExitOnError)
+	cfg, err := config.Load(flags, os.Args[1:])
+	if *printConfig && cfg != nil {
+		if err := cfg.WriteYAML(os.Stdout); err != nil {
+			logging.Fatal("Failed to print configuration", "error", err)
+		}
+		if err != nil {
+			logging.Fatal("Invalid configuration", "error", err)
+		}
+		return
+	}
+	if err != nil {
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+
 	fmt.Println("Starting demo service...")
 	metrics.Init()
 
-	cfg := config.Load()
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Options())
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
 
-	pool, err := db.NewPool(cfg.PostgresURL)
+	pool, err := db.NewPool(cfg.Database.URL, cfg.Database.Pool())
 	if err != nil {
-		log.Fatal("Failed to connect to DB:", err)
+		logging.Fatal("Failed to connect to DB", "error", err)
 	}
 	defer pool.Close()
+	metrics.DBPools.Add("primary", pool)
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		logging.Fatal("Failed to load migrations", "error", err)
+	}
+	if cfg.Database.AutoMigrate {
+		n, err := migrator.Up(context.Background())
+		if err != nil {
+			logging.Fatal("Failed to apply migrations", "error", err)
+		}
+		slog.Info("Applied migrations", "count", n)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		logging.Fatal("Database schema check failed", "error", err)
+	}
+
+	keyring, err := cfg.PII.Keyring()
+	if err != nil {
+		logging.Fatal("Failed to load PII keyring", "error", err)
+	}
 
 	orderRepo := repository.NewOrderRepository(pool)
-	cache := service.NewMemoryCache(100)
+	var cluster *db.Cluster
+	if len(cfg.Database.ReplicaURLs) > 0 {
+		cluster, err = db.NewCluster(pool, cfg.Database.ReplicaURLs, cfg.Database.Pool(), cfg.Database.ReplicaMaxLag)
+		if err != nil {
+			logging.Fatal("Failed to set up read replicas", "error", err)
+		}
+		defer cluster.Close()
+		orderRepo = repository.NewReplicatedOrderRepository(cluster)
+	}
+	orderRepo.SetKeyring(keyring)
+	cache := service.NewMemoryCache(cfg.Cache.Size, cfg.Cache.TTL)
 	orderSvc := service.NewOrderService(orderRepo, cache)
-	orderHandler := handlers.NewOrderHandler(orderSvc)
+	orderHandler := handlers.NewOrderHandler(orderSvc, cfg.HTTP.StrictJSON)
+	if cfg.PII.Mask {
+		role, err := auth.ParseRole(cfg.PII.UnmaskRole)
+		if err != nil {
+			logging.Fatal("Invalid PII unmask role", "error", err)
+		}
+		orderHandler.MaskPII(role)
+	}
+	orderHandler.SetBodyLimits(int64(cfg.HTTP.MaxBodyBytes), int64(cfg.HTTP.MaxImportBytes))
+	limiter := ratelimit.New(cfg.HTTP.RateLimitRPS, cfg.HTTP.RateLimitBurst)
 
-	if err := orderSvc.LoadCache(context.Background()); err != nil {
-		log.Println("Failed to load cache:", err)
+	var registry kafka.SchemaRegistry
+	if cfg.Kafka.SchemaRegistryURL != "" {
+		registry = kafka.NewRegistryClient(cfg.Kafka.SchemaRegistryURL)
 	}
 
+	codecs := kafka.NewCodecs(registry, cfg.Kafka.StrictJSON)
+	kafkaSec, err := cfg.Kafka.Security()
+	if err != nil {
+		logging.Fatal("Failed to set up Kafka TLS and SASL", "error", err)
+	}
 	consumer := kafka.NewConsumer(
-		[]string{cfg.KafkaBrokers},
-		"orders",
-		"order-service-group",
+		cfg.Kafka.Brokers,
+		cfg.Kafka.Topic,
+		cfg.Kafka.DLQTopic,
+		cfg.Kafka.GroupID,
+		kafkaSec,
 		orderSvc,
+		codecs,
 	)
 	defer func() {
 		if err := consumer.Close(); err != nil {
-			log.Println("Error closing Kafka consumer:", err)
+			slog.Error("Error closing Kafka consumer", "error", err)
 		}
 	}()
 
 	consumerCtx, consumerCancel := context.WithCancel(context.Background())
 	go func() {
 		if err := consumer.Consume(consumerCtx); err != nil {
-			log.Println("Kafka consumer error:", err)
+			slog.Error("Kafka consumer error", "error", err)
 		}
 	}()
 
+	maintenanceCtx, maintenanceCancel := context.WithCancel(context.Background())
+	if cfg.Partition.MaintenanceInterval > 0 {
+		partitions := partition.NewManager(pool, partition.Config{
+			Premake:   cfg.Partition.PremakeMonths,
+			Retention: cfg.Partition.RetentionMonths,
+			Archive:   cfg.Partition.Archive,
+		})
+		partitions.OnRetire(func(before time.Time) {
+			if n := orderSvc.EvictCreatedBefore(before); n > 0 {
+				slog.Info("Evicted retired orders from cache", "evicted", n, "before", before.Format(time.DateOnly))
+			}
+		})
+		go partitions.Run(maintenanceCtx, cfg.Partition.MaintenanceInterval)
+	}
+	if cluster != nil {
+		go cluster.RunHealthChecks(maintenanceCtx, cfg.Database.ReplicaCheckInterval)
+	}
+	go warmCache(maintenanceCtx, orderSvc)
+
+	// /healthz only tells that the process serves requests; /readyz also
+	// checks the dependencies orders need.
+	liveness := health.NewRegistry("liveness", cfg.Admin.HealthTimeout)
+	readiness := health.NewRegistry("readiness", cfg.Admin.HealthTimeout)
+	readiness.Register("postgres", pool.Ping)
+	readiness.Register("kafka_consumer", consumer.Check(cfg.Kafka.StallTimeout))
+	readiness.Register("cache", orderSvc.CheckCache)
+
+	// Reloads re-read the same file, environment and flags as startup.
+	reloader := config.NewReloader(cfg, func() (*config.Config, error) {
+		fs, _ := newFlagSet(flag.ContinueOnError)
+		return config.Load(fs, os.Args[1:])
+	})
+	reloader.OnReload(func(c *config.Config) {
+		if err := logging.SetLevel(c.Log.Level); err != nil {
+			slog.Error("Failed to change log level", "error", err)
+		}
+		cache.Resize(c.Cache.Size)
+		cache.SetTTL(c.Cache.TTL)
+		orderHandler.SetStrictJSON(c.HTTP.StrictJSON)
+		orderHandler.SetBodyLimits(int64(c.HTTP.MaxBodyBytes), int64(c.HTTP.MaxImportBytes))
+		limiter.SetLimit(c.HTTP.RateLimitRPS, c.HTTP.RateLimitBurst)
+		codecs.SetStrictJSON(c.Kafka.StrictJSON)
+	})
+	auditRepo := repository.NewAuditRepository(pool)
+	reloader.AuditTo(auditRepo)
+	go reloader.WatchSignals(maintenanceCtx)
+	configHandler := handlers.NewConfigHandler(reloader)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+
+	dlq := kafka.NewDLQ(cfg.Kafka.Brokers, cfg.Kafka.Topic, cfg.Kafka.DLQTopic, cfg.Kafka.GroupID, kafkaSec)
+	dlq.AuditTo(auditRepo)
+	dlqHandler := handlers.NewDLQHandler(dlq)
+	cacheHandler := handlers.NewCacheHandler(orderSvc, auditRepo)
+
+	authn, err := cfg.Auth.Authenticator()
+	if err != nil {
+		logging.Fatal("Failed to set up authentication", "error", err)
+	}
+	if authn == nil {
+		slog.Warn("Authentication is disabled, the API is open to anyone who can reach it")
+	}
+
+	certs, clientCAs, err := cfg.HTTP.ServerTLS()
+	if err != nil {
+		logging.Fatal("Failed to set up TLS", "error", err)
+	}
+
+	// protect authenticates requests, then rate-limits them per principal,
+	// or per client IP when authentication is disabled. Failed
+	// authentication attempts are rate-limited per client IP.
+	protect := func(role auth.Role, h http.Handler) http.Handler {
+		return limiter.LimitFailures(authn.Require(role, limiter.Limit(h)))
+	}
+	// admin additionally requires a verified client certificate when
+	// client CAs are configured.
+	admin := func(h http.Handler) http.Handler {
+		h = protect(auth.RoleAdmin, h)
+		if clientCAs != nil {
+			h = tlsutil.RequireClientCert(h)
+		}
+		return h
+	}
+
+	// The public listener serves the order API and the web UI only.
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("pong"))
 	})
 
-	mux.Handle("/metrics", promhttp.Handler())
+	web.RegisterRoutes(mux)
 
-	mux.Handle("/swagger/", httpSwagger.WrapHandler)
+	mux.Handle("POST /order", protect(auth.RoleWriter, http.HandlerFunc(orderHandler.CreateOrder)))
+	mux.Handle("GET /order/{uid}", protect(auth.RoleReader, http.HandlerFunc(orderHandler.GetOrderByUID)))
+	mux.Handle("GET /orders/export", protect(auth.RoleReader, http.HandlerFunc(orderHandler.ExportOrders)))
+	mux.Handle("POST /orders/import", protect(auth.RoleWriter, http.HandlerFunc(orderHandler.ImportOrders)))
+	mux.HandleFunc("GET /schema/order.json", orderHandler.GetOrderSchema)
 
-	web.RegisterRoutes(mux)
+	// The admin listener serves monitoring and operators. Health probes
+	// stay open to the orchestrator; everything else requires the admin
+	// role.
+	adminMux := http.NewServeMux()
 
-	mux.HandleFunc("POST /order", orderHandler.CreateOrder)
-	mux.HandleFunc("GET /order/{uid}", orderHandler.GetOrderByUID)
+	adminMux.Handle("GET /healthz", liveness.Handler())
+	adminMux.Handle("GET /readyz", readiness.Handler())
 
-	srv := &http.Server{
-		Addr:    ":8081",
-		Handler: metrics.MetricsMiddleware(mux),
+	adminMux.Handle("/metrics", admin(metrics.Handler()))
+	adminMux.Handle("/swagger/", admin(httpSwagger.WrapHandler))
+	if cfg.Admin.Pprof {
+		registerPprof(adminMux, admin)
 	}
 
-	serverErr := make(chan error, 1)
+	adminMux.Handle("GET /customers/{customer_id}/data-export", admin(http.HandlerFunc(orderHandler.ExportCustomerData)))
+	adminMux.Handle("POST /customers/{customer_id}/erase", admin(http.HandlerFunc(orderHandler.EraseCustomer)))
+	adminMux.Handle("GET /admin/config", admin(http.HandlerFunc(configHandler.GetConfig)))
+	adminMux.Handle("POST /admin/config/reload", admin(http.HandlerFunc(configHandler.ReloadConfig)))
+	adminMux.Handle("GET /audit", admin(http.HandlerFunc(auditHandler.ListAudit)))
+	adminMux.Handle("GET /cache/stats", admin(http.HandlerFunc(cacheHandler.GetCacheStats)))
+	adminMux.Handle("DELETE /cache/orders/{uid}", admin(http.HandlerFunc(cacheHandler.EvictOrder)))
+	adminMux.Handle("DELETE /cache", admin(http.HandlerFunc(cacheHandler.ClearCache)))
+	adminMux.Handle("POST /cache/warm", admin(http.HandlerFunc(cacheHandler.WarmCache)))
+	adminMux.Handle("GET /dlq/messages", admin(http.HandlerFunc(dlqHandler.ListDLQ)))
+	adminMux.Handle("POST /dlq/replay", admin(http.HandlerFunc(dlqHandler.ReplayDLQ)))
 
-	go func() {
-		log.Println("HTTP server started at :8081")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("ListenAndServe error: %v", err)
+	// Both listeners share the middleware, timeouts and TLS settings.
+	newServer := func(addr string, mux *http.ServeMux) *http.Server {
+		srv := &http.Server{
+			Addr:              addr,
+			Handler:           otelhttp.NewHandler(metrics.MetricsMiddleware(logging.Middleware(tracing.Routes(metrics.Routes(mux)))), "http.request"),
+			ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+			ReadTimeout:       cfg.HTTP.ReadTimeout,
+			WriteTimeout:      cfg.HTTP.WriteTimeout,
+			IdleTimeout:       cfg.HTTP.IdleTimeout,
+		}
+		if certs != nil {
+			srv.TLSConfig = tlsutil.ServerConfig(certs, clientCAs)
+		}
+		return srv
+	}
+	srv := newServer(cfg.HTTP.Addr, mux)
+	adminSrv := newServer(cfg.Admin.Addr, adminMux)
+	if certs != nil {
+		go certs.Watch(maintenanceCtx, cfg.HTTP.TLSReloadInterval)
+	}
+
+	serverErr := make(chan error, 2)
+
+	serve := func(name string, srv *http.Server) {
+		slog.Info(name+" server started", "addr", srv.Addr, "tls", certs != nil, "client_certs", clientCAs != nil)
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("ListenAndServe error", "server", name, "error", err)
 			serverErr <- err
 		}
-	}()
+	}
+	go serve("HTTP", srv)
+	go serve("Admin", adminSrv)
+
+	shutdown.GracefulShutdown([]*http.Server{srv, adminSrv}, serverErr, cfg.HTTP.ShutdownTimeout, consumerCancel, maintenanceCancel)
+}
+
+// warmCache loads the cache from the repository, retrying until it
+// succeeds or ctx is done; /readyz fails until then.
+func warmCache(ctx context.Context, svc *service.OrderService) {
+	for {
+		n, err := svc.LoadCache(ctx)
+		if err == nil {
+			slog.Info("Cache warmed", "orders", n)
+			return
+		}
+		slog.Error("Failed to load cache, retrying", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// registerPprof serves the runtime profiles under /debug/pprof/, each
+// handler wrapped with wrap.
+func registerPprof(mux *http.ServeMux, wrap func(http.Handler) http.Handler) {
+	mux.Handle("/debug/pprof/", wrap(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", wrap(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", wrap(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", wrap(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", wrap(http.HandlerFunc(pprof.Trace)))
+}
 
-	shutdown.GracefulShutdown(srv, serverErr, consumerCancel)
+// newFlagSet returns the service's own flags; config.Load adds the
+// configuration flags to it.
+func newFlagSet(errorHandling flag.ErrorHandling) (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet("service", errorHandling)
+	printConfig := fs.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	return fs, printConfig
 }
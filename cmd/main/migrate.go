@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sonni-a/wb-service/internal/config"
+	"github.com/sonni-a/wb-service/internal/db"
+	"github.com/sonni-a/wb-service/internal/logging"
+	"github.com/sonni-a/wb-service/internal/migrate"
+	"github.com/sonni-a/wb-service/migrations"
+)
+
+const migrateUsage = `usage: service migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [-steps N] roll back the last N migrations (default 1)
+  status          list migrations and the current schema version
+`
+
+// runMigrate implements the "migrate" subcommand and returns the exit code.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	cfg, err := config.Load(fs, args[1:])
+	if cfg == nil {
+		return 2
+	}
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return 1
+	}
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+		slog.Error("Failed to set up logging", "error", err)
+		return 1
+	}
+
+	pool, err := db.NewPool(cfg.Database.URL, cfg.Database.Pool())
+	if err != nil {
+		slog.Error("Failed to connect to DB", "error", err)
+		return 1
+	}
+	defer pool.Close()
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		slog.Error("Failed to load migrations", "error", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			slog.Error("Migration failed", "error", err)
+			return 1
+		}
+		slog.Info("Applied migrations", "count", n, "version", migrator.Latest())
+
+	case "down":
+		n, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			slog.Error("Rollback failed", "error", err)
+			return 1
+		}
+		slog.Info("Rolled back migrations", "count", n)
+
+	case "status":
+		statuses, version, dirty, err := migrator.Status(ctx)
+		if err != nil {
+			slog.Error("Failed to read migration status", "error", err)
+			return 1
+		}
+
+		fmt.Printf("schema version: %d (expected %d", version, migrator.Latest())
+		if dirty {
+			fmt.Print(", dirty")
+		}
+		fmt.Println(")")
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, state)
+		}
+		_ = w.Flush()
+
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
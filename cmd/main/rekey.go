@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os/signal"
+	"syscall"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/config"
+	"github.com/sonni-a/wb-service/internal/db"
+	"github.com/sonni-a/wb-service/internal/logging"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+// runRekey implements the "rekey" subcommand, which encrypts the delivery
+// PII of all stored orders with the primary key of the PII keyring, and
+// returns the exit code. It is run after enabling encryption and after
+// adding a new primary key; old keys can be removed from the keyring once
+// it has finished.
+func runRekey(args []string) int {
+	fs := flag.NewFlagSet("rekey", flag.ContinueOnError)
+	batchSize := fs.Int("batch-size", 500, "number of orders re-encrypted per transaction")
+	cfg, err := config.Load(fs, args)
+	if cfg == nil {
+		return 2
+	}
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return 1
+	}
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+		slog.Error("Failed to set up logging", "error", err)
+		return 1
+	}
+	if *batchSize <= 0 {
+		slog.Error("Batch size must be positive", "batch_size", *batchSize)
+		return 2
+	}
+
+	keyring, err := cfg.PII.Keyring()
+	if err != nil {
+		slog.Error("Failed to load PII keyring", "error", err)
+		return 1
+	}
+	if keyring == nil {
+		slog.Error("No PII keyring configured, set pii.keyring_file")
+		return 1
+	}
+
+	pool, err := db.NewPool(cfg.Database.URL, cfg.Database.Pool())
+	if err != nil {
+		slog.Error("Failed to connect to DB", "error", err)
+		return 1
+	}
+	defer pool.Close()
+
+	repo := repository.NewOrderRepository(pool)
+	repo.SetKeyring(keyring)
+
+	ctx, stop := signal.NotifyContext(audit.WithActor(context.Background(), audit.CurrentUser()), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	n, err := repo.RekeyDeliveries(ctx, *batchSize)
+	if err != nil {
+		slog.Error("Re-encryption failed", "rekeyed", n, "error", err)
+		return 1
+	}
+	slog.Info("Re-encrypted deliveries", "count", n)
+	return 0
+}
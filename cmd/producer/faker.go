@@ -1,10 +1,10 @@
 package main
 
 import (
-	"log"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
+	"github.com/sonni-a/wb-service/internal/logging"
 	"github.com/sonni-a/wb-service/internal/models"
 )
 
@@ -12,7 +12,7 @@ func ptr[T any](v T) *T { return &v }
 
 func initFaker() {
 	if err := gofakeit.Seed(time.Now().UnixNano()); err != nil {
-		log.Fatalf("failed to seed faker: %v", err)
+		logging.Fatal("Failed to seed faker", "error", err)
 	}
 }
 
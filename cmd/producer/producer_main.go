@@ -2,29 +2,64 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/sonni-a/wb-service/internal/config"
 	"github.com/sonni-a/wb-service/internal/kafka"
+	"github.com/sonni-a/wb-service/internal/logging"
+	"github.com/sonni-a/wb-service/internal/tracing"
 )
 
 func main() {
 	initFaker()
 
-	cfg := config.Load()
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+	if err := logging.Setup(cfg.Log.Level, cfg.Log.Format); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
 
 	ctx := context.Background()
-	topic := "orders"
-	brokers := []string{cfg.KafkaBrokers}
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Options())
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer func() {
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
+	topic := cfg.Kafka.Topic
+	brokers := cfg.Kafka.Brokers
+
+	var registry kafka.SchemaRegistry
+	if cfg.Kafka.SchemaRegistryURL != "" {
+		registry = kafka.NewRegistryClient(cfg.Kafka.SchemaRegistryURL)
+	}
+
+	codec, err := kafka.NewCodecs(registry, false).ByName(cfg.Kafka.MessageFormat)
+	if err != nil {
+		logging.Fatal("Unsupported message format", "error", err)
+	}
+
+	sec, err := cfg.Kafka.Security()
+	if err != nil {
+		logging.Fatal("Failed to set up Kafka TLS and SASL", "error", err)
+	}
 
 	for i := 0; i < 5; i++ {
 		order := generateFakeOrder()
 
-		if err := kafka.SendOrder(ctx, brokers, topic, &order); err != nil {
-			log.Println("Failed to send order:", err)
+		if err := kafka.SendOrderWithCodec(ctx, brokers, sec, topic, codec, &order); err != nil {
+			slog.Error("Failed to send order", logging.KeyOrderUID, order.OrderUID, "error", err)
 		} else {
-			log.Println("Order sent successfully:", order.OrderUID)
+			slog.Info("Order sent successfully", logging.KeyOrderUID, order.OrderUID)
 		}
 
 		time.Sleep(time.Second)
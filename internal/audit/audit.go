@@ -0,0 +1,61 @@
+// Package audit carries who is making a change through the context, so that
+// the repository can record it in the audit log along with the change.
+package audit
+
+import (
+	"context"
+	"os/user"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+// Sources of audited changes.
+const (
+	SourceHTTP  = "http"
+	SourceKafka = "kafka"
+	SourceCLI   = "cli"
+	// SourceSignal is a change triggered by a process signal, such as a
+	// configuration reload on SIGHUP.
+	SourceSignal = "signal"
+)
+
+// Recorder appends entries to the audit log. It is used for changes that
+// are not made inside a repository transaction, such as configuration
+// reloads; the actor is taken from the context.
+type Recorder interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+// Actor is who made a change and through which source.
+type Actor struct {
+	// Name is the API key name or token subject for HTTP requests, the
+	// consumer group for Kafka and the OS user for command-line tools.
+	Name   string
+	Source string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are attributed to a.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFrom returns the actor attached with WithActor, or an unknown actor
+// if there is none.
+func ActorFrom(ctx context.Context) Actor {
+	if a, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return a
+	}
+	return Actor{Name: "unknown", Source: "unknown"}
+}
+
+// CurrentUser is the actor for command-line tools: the OS user running the
+// process.
+func CurrentUser() Actor {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return Actor{Name: name, Source: SourceCLI}
+}
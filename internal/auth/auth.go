@@ -0,0 +1,198 @@
+// Package auth authenticates HTTP requests with static API keys or JWT
+// bearer tokens and authorizes them by role.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/sonni-a/wb-service/internal/logging"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	KeyPrincipal = "principal"
+)
+
+// Role grants access to a group of routes. Roles are ordered: a writer can
+// do everything a reader can, and an admin everything a writer can.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleReader
+	RoleWriter
+	RoleAdmin
+)
+
+var roleNames = map[string]Role{
+	"reader": RoleReader,
+	"writer": RoleWriter,
+	"admin":  RoleAdmin,
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleReader:
+		return "reader"
+	case RoleWriter:
+		return "writer"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+func ParseRole(s string) (Role, error) {
+	if role, ok := roleNames[s]; ok {
+		return role, nil
+	}
+	return RoleNone, fmt.Errorf("unknown role %q, want reader, writer or admin", s)
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject is the API key name or the sub claim of the token.
+	Subject string
+	Role    Role
+	// Method is api_key or jwt.
+	Method string
+}
+
+type principalKey struct{}
+
+// FromContext returns the principal Require attached to the request
+// context, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// APIKey is a static key, stored as the hex SHA-256 of the key itself.
+type APIKey struct {
+	Name string
+	Role Role
+	Hash [sha256.Size]byte
+}
+
+// ParseAPIKey parses a name:role:sha256-hex entry, e.g. the output of
+// `echo -n "$KEY" | sha256sum` prefixed with a name and a role.
+func ParseAPIKey(s string) (APIKey, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return APIKey{}, errors.New("API key must be name:role:sha256-hex")
+	}
+	role, err := ParseRole(parts[1])
+	if err != nil {
+		return APIKey{}, fmt.Errorf("API key %s: %w", parts[0], err)
+	}
+	sum, err := hex.DecodeString(parts[2])
+	if err != nil || len(sum) != sha256.Size {
+		return APIKey{}, fmt.Errorf("API key %s: hash must be 64 hex characters", parts[0])
+	}
+
+	key := APIKey{Name: parts[0], Role: role}
+	copy(key.Hash[:], sum)
+	return key, nil
+}
+
+// Authenticator checks the credentials of requests. A nil *Authenticator
+// lets every request through, which is how authentication is disabled.
+type Authenticator struct {
+	keys []APIKey
+	jwt  *JWTVerifier
+}
+
+// New returns an authenticator accepting the given API keys and, if
+// verifier is not nil, JWT bearer tokens.
+func New(keys []APIKey, verifier *JWTVerifier) *Authenticator {
+	return &Authenticator{keys: keys, jwt: verifier}
+}
+
+var (
+	errNoCredentials      = errors.New("missing credentials")
+	errInvalidAPIKey      = errors.New("invalid API key")
+	errBearerNotSupported = errors.New("bearer tokens are not accepted")
+)
+
+// authenticate returns the principal of r, or an error if r carries no or
+// invalid credentials.
+func (a *Authenticator) authenticate(r *http.Request) (Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.checkAPIKey(key)
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, errNoCredentials
+	}
+	if a.jwt == nil {
+		return Principal{}, errBearerNotSupported
+	}
+	return a.jwt.Verify(strings.TrimSpace(token))
+}
+
+func (a *Authenticator) checkAPIKey(key string) (Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	// Compare against every key so the time taken does not tell which
+	// prefix of a hash matched.
+	var found *APIKey
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], a.keys[i].Hash[:]) == 1 {
+			found = &a.keys[i]
+		}
+	}
+	if found == nil {
+		return Principal{}, errInvalidAPIKey
+	}
+	return Principal{Subject: found.Name, Role: found.Role, Method: "api_key"}, nil
+}
+
+// Require lets a request through to next only if it is authenticated with
+// at least role. Otherwise it answers 401 for missing or invalid
+// credentials and 403 for a role that is too low.
+func (a *Authenticator) Require(role Role, next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.authenticate(r)
+		if err != nil {
+			slog.DebugContext(r.Context(), "Authentication failed", "error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="order-service"`)
+			writeError(w, http.StatusUnauthorized, "unauthorized", err.Error())
+			return
+		}
+
+		ctx := logging.With(r.Context(), KeyPrincipal, p.Subject)
+		if p.Role < role {
+			slog.InfoContext(ctx, "Access denied", "role", p.Role.String(), "required", role.String())
+			writeError(w, http.StatusForbidden, "forbidden", fmt.Sprintf("role %s is required", role))
+			return
+		}
+
+		ctx = context.WithValue(ctx, principalKey{}, p)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Error is the body of 401 and 403 responses.
+type Error struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(Error{Error: code, Message: msg})
+}
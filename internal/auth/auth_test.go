@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func apiKey(t *testing.T, name, role, key string) APIKey {
+	t.Helper()
+	sum := sha256.Sum256([]byte(key))
+	k, err := ParseAPIKey(name + ":" + role + ":" + hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// serve runs a request with the given headers through Require(role) and
+// returns the response and the principal the handler saw, if it ran.
+func serve(a *Authenticator, role Role, headers map[string]string) (*httptest.ResponseRecorder, *Principal) {
+	var seen *Principal
+	h := a.Require(role, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := FromContext(r.Context()); ok {
+			seen = &p
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/order/abc", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, seen
+}
+
+func TestRequire_APIKey(t *testing.T) {
+	a := New([]APIKey{
+		apiKey(t, "dashboard", "reader", "read-secret"),
+		apiKey(t, "ingest", "writer", "write-secret"),
+	}, nil)
+
+	tests := []struct {
+		name    string
+		role    Role
+		headers map[string]string
+		status  int
+		subject string
+	}{
+		{"valid key", RoleReader, map[string]string{APIKeyHeader: "read-secret"}, http.StatusNoContent, "dashboard"},
+		{"higher role", RoleReader, map[string]string{APIKeyHeader: "write-secret"}, http.StatusNoContent, "ingest"},
+		{"missing", RoleReader, nil, http.StatusUnauthorized, ""},
+		{"invalid key", RoleReader, map[string]string{APIKeyHeader: "guess"}, http.StatusUnauthorized, ""},
+		{"bearer without JWKS", RoleReader, map[string]string{"Authorization": "Bearer abc"}, http.StatusUnauthorized, ""},
+		{"role too low", RoleWriter, map[string]string{APIKeyHeader: "read-secret"}, http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, p := serve(a, tt.role, tt.headers)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusNoContent {
+				if p == nil || p.Subject != tt.subject || p.Method != "api_key" {
+					t.Errorf("principal = %+v, want subject %s", p, tt.subject)
+				}
+				return
+			}
+			if p != nil {
+				t.Error("handler ran for a rejected request")
+			}
+
+			var body Error
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == "" || body.Message == "" {
+				t.Errorf("body = %q, want a JSON error", rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestRequire_NilAuthenticatorAllowsAll(t *testing.T) {
+	var a *Authenticator
+	rec, p := serve(a, RoleAdmin, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if p != nil {
+		t.Errorf("principal = %+v, want none", p)
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	hash := hex.EncodeToString(make([]byte, sha256.Size))
+	for _, s := range []string{
+		"",
+		"ci:writer",
+		":writer:" + hash,
+		"ci:root:" + hash,
+		"ci:writer:abc",
+		"ci:writer:" + hash + "00",
+	} {
+		if _, err := ParseAPIKey(s); err == nil {
+			t.Errorf("ParseAPIKey(%q) succeeded, want error", s)
+		}
+	}
+
+	k, err := ParseAPIKey("ci:admin:" + hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Name != "ci" || k.Role != RoleAdmin {
+		t.Errorf("key = %+v", k)
+	}
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newJWKS writes a JWKS holding the public half of a fresh P-256 key under
+// kid and returns the private key for signing.
+func newJWKS(t *testing.T, kid string) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := base64.RawURLEncoding
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "EC",
+		"kid": kid,
+		"use": "sig",
+		"crv": "P-256",
+		"x":   enc.EncodeToString(priv.X.FillBytes(make([]byte, 32))),
+		"y":   enc.EncodeToString(priv.Y.FillBytes(make([]byte, 32))),
+	}}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return priv, path
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestJWTVerifier(t *testing.T) {
+	key, path := newJWKS(t, "k1")
+	v, err := NewJWTVerifier(JWTConfig{JWKSFile: path, Issuer: "https://idp", Audience: "orders", RoleClaim: "roles"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := func(extra jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{"sub": "alice", "iss": "https://idp", "aud": "orders", "exp": exp}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+	other, _ := newJWKS(t, "k1")
+
+	tests := []struct {
+		name  string
+		token string
+		role  Role
+		ok    bool
+	}{
+		{"roles list", sign(t, key, "k1", claims(jwt.MapClaims{"roles": []string{"reader", "writer", "ops"}})), RoleWriter, true},
+		{"role string", sign(t, key, "k1", claims(jwt.MapClaims{"roles": "admin"})), RoleAdmin, true},
+		{"no roles", sign(t, key, "k1", claims(nil)), RoleNone, true},
+		{"no kid with single key", sign(t, key, "", claims(jwt.MapClaims{"roles": "reader"})), RoleReader, true},
+		{"expired", sign(t, key, "k1", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})), 0, false},
+		{"no exp", sign(t, key, "k1", jwt.MapClaims{"sub": "alice", "iss": "https://idp", "aud": "orders"}), 0, false},
+		{"wrong issuer", sign(t, key, "k1", claims(jwt.MapClaims{"iss": "https://evil"})), 0, false},
+		{"wrong audience", sign(t, key, "k1", claims(jwt.MapClaims{"aud": "billing"})), 0, false},
+		{"no sub", sign(t, key, "k1", claims(jwt.MapClaims{"sub": ""})), 0, false},
+		{"unknown kid", sign(t, key, "k2", claims(nil)), 0, false},
+		{"wrong key", sign(t, other, "k1", claims(nil)), 0, false},
+		{"unsigned", func() string {
+			s, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return s
+		}(), 0, false},
+		{"garbage", "not.a.token", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(tt.token)
+			if !tt.ok {
+				if err == nil {
+					t.Errorf("Verify succeeded with %+v, want error", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Subject != "alice" || p.Role != tt.role || p.Method != "jwt" {
+				t.Errorf("principal = %+v, want alice with role %s", p, tt.role)
+			}
+		})
+	}
+}
+
+func TestRequire_BearerToken(t *testing.T) {
+	key, path := newJWKS(t, "k1")
+	v, err := NewJWTVerifier(JWTConfig{JWKSFile: path, RoleClaim: "roles"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New(nil, v)
+	token := sign(t, key, "k1", jwt.MapClaims{"sub": "svc", "roles": "writer", "exp": time.Now().Add(time.Minute).Unix()})
+
+	rec, p := serve(a, RoleWriter, map[string]string{"Authorization": "Bearer " + token})
+	if rec.Code != http.StatusNoContent || p == nil || p.Subject != "svc" {
+		t.Errorf("status = %d, principal = %+v", rec.Code, p)
+	}
+
+	rec, _ = serve(a, RoleAdmin, map[string]string{"Authorization": "Bearer " + token})
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	for name, doc := range map[string]string{
+		"not json":        `{`,
+		"empty":           `{"keys":[]}`,
+		"only enc":        `{"keys":[{"kty":"OKP","crv":"Ed25519","use":"enc","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`,
+		"unknown kty":     `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`,
+		"short RSA":       `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`,
+		"bad curve":       `{"keys":[{"kty":"EC","crv":"P-224","x":"AA","y":"AA"}]}`,
+		"point off curve": `{"keys":[{"kty":"EC","crv":"P-256","x":"` + zeros(32) + `","y":"` + zeros(32) + `"}]}`,
+		"duplicate kid": `{"keys":[
+			{"kty":"OKP","crv":"Ed25519","kid":"a","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+			{"kty":"OKP","crv":"Ed25519","kid":"a","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`,
+	} {
+		if _, err := ParseJWKS([]byte(doc)); err == nil {
+			t.Errorf("%s: ParseJWKS succeeded, want error", name)
+		}
+	}
+
+	keys, err := ParseJWKS([]byte(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"ed","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := keys["ed"]; !ok {
+		t.Errorf("keys = %v, want ed", keys)
+	}
+}
+
+func zeros(n int) string {
+	return base64.RawURLEncoding.EncodeToString(make([]byte, n))
+}
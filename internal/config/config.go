@@ -1,28 +1,356 @@
 package config
 
 import (
-	"log"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/auth"
+	"github.com/sonni-a/wb-service/internal/db"
+	"github.com/sonni-a/wb-service/internal/kafka"
+	"github.com/sonni-a/wb-service/internal/pii"
+	"github.com/sonni-a/wb-service/internal/tlsutil"
+	"github.com/sonni-a/wb-service/internal/tracing"
 )
 
+// Config is the service configuration. Every setting can come from the YAML
+// file, from the environment variable in its env tag or from a command-line
+// flag named after its YAML path (--kafka.topic); see Load. Settings tagged
+// reload:"true" can be changed at runtime; see Reloader.
 type Config struct {
-	PostgresURL  string
-	KafkaBrokers string
+	Log       LogConfig       `yaml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	HTTP      HTTPConfig      `yaml:"http"`
+	Admin     AdminConfig     `yaml:"admin"`
+	Auth      AuthConfig      `yaml:"auth"`
+	PII       PIIConfig       `yaml:"pii"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Kafka     KafkaConfig     `yaml:"kafka"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Partition PartitionConfig `yaml:"partition"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" default:"info" reload:"true" usage:"minimum log level: debug, info, warn or error"`
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"json" usage:"log output format: json or text"`
+}
+
+type TracingConfig struct {
+	Exporter     string `yaml:"exporter" env:"TRACING_EXPORTER" default:"none" usage:"where spans are sent: none, stdout or otlp"`
+	OTLPEndpoint string `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" usage:"OTLP/HTTP collector URL, e.g. http://otel-collector:4318"`
+	ServiceName  string `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"order-service" usage:"service.name of exported spans"`
+}
+
+type HTTPConfig struct {
+	Addr            string        `yaml:"addr" env:"HTTP_ADDR" default:":8081" usage:"HTTP listen address"`
+	StrictJSON      bool          `yaml:"strict_json" env:"STRICT_JSON_HTTP" default:"false" reload:"true" usage:"reject unknown fields and type mismatches in request bodies"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"10s" usage:"time to finish in-flight requests on shutdown"`
+
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" default:"5s" usage:"time to read request headers"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" default:"30s" usage:"time to read a whole request, 0 for none; not applied to imports"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" default:"60s" usage:"time to write a response, 0 for none; not applied to exports and imports"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" default:"120s" usage:"time a keep-alive connection may stay idle"`
+
+	MaxBodyBytes   int `yaml:"max_body_bytes" env:"HTTP_MAX_BODY_BYTES" default:"1048576" reload:"true" usage:"largest accepted order request body, larger ones get 413"`
+	MaxImportBytes int `yaml:"max_import_bytes" env:"HTTP_MAX_IMPORT_BYTES" default:"104857600" reload:"true" usage:"largest accepted import request body, larger ones get 413"`
+
+	// RateLimitRPS and RateLimitBurst configure a token bucket per API key,
+	// or per client IP for unauthenticated requests.
+	RateLimitRPS   int `yaml:"rate_limit_rps" env:"HTTP_RATE_LIMIT_RPS" default:"0" reload:"true" usage:"sustained requests per second allowed per API key or client IP, 0 disables rate limiting"`
+	RateLimitBurst int `yaml:"rate_limit_burst" env:"HTTP_RATE_LIMIT_BURST" default:"20" reload:"true" usage:"requests a caller may make at once above the sustained rate"`
+
+	TLSCertFile       string        `yaml:"tls_cert_file" env:"HTTP_TLS_CERT_FILE" usage:"PEM certificate chain to serve HTTPS with, empty serves plain HTTP"`
+	TLSKeyFile        string        `yaml:"tls_key_file" env:"HTTP_TLS_KEY_FILE" usage:"PEM private key of tls_cert_file"`
+	TLSClientCAFile   string        `yaml:"tls_client_ca_file" env:"HTTP_TLS_CLIENT_CA_FILE" usage:"PEM CA bundle client certificates are verified against; admin routes then require a verified client certificate"`
+	TLSReloadInterval time.Duration `yaml:"tls_reload_interval" env:"HTTP_TLS_RELOAD_INTERVAL" default:"1m" usage:"how often the certificate files are checked for changes"`
+}
+
+// AdminConfig is the listener for operators: metrics, profiling, health
+// probes, cache and DLQ administration. It shares the TLS settings and
+// timeouts of the HTTP listener.
+type AdminConfig struct {
+	Addr  string `yaml:"addr" env:"ADMIN_ADDR" default:":8082" usage:"admin listen address, keep it off the public network"`
+	Pprof bool   `yaml:"pprof" env:"ADMIN_PPROF" default:"true" usage:"serve /debug/pprof on the admin listener"`
+
+	HealthTimeout time.Duration `yaml:"health_timeout" env:"ADMIN_HEALTH_TIMEOUT" default:"2s" usage:"time each /healthz and /readyz check may take before it counts as down"`
+}
+
+type AuthConfig struct {
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED" default:"false" usage:"require API keys or JWTs on the API"`
+	// APIKeys are name:role:sha256-hex entries; only the hashes of the keys
+	// are configured.
+	APIKeys      []string `yaml:"api_keys" env:"AUTH_API_KEYS" secret:"true" usage:"comma-separated name:role:sha256-hex API keys, role is reader, writer or admin"`
+	JWKSFile     string   `yaml:"jwks_file" env:"AUTH_JWKS_FILE" usage:"JWKS file with the keys JWTs are signed with, empty disables JWTs"`
+	JWTIssuer    string   `yaml:"jwt_issuer" env:"AUTH_JWT_ISSUER" usage:"required iss claim of JWTs"`
+	JWTAudience  string   `yaml:"jwt_audience" env:"AUTH_JWT_AUDIENCE" usage:"required aud claim of JWTs"`
+	JWTRoleClaim string   `yaml:"jwt_role_claim" env:"AUTH_JWT_ROLE_CLAIM" default:"roles" usage:"JWT claim holding the caller's roles"`
+}
+
+type PIIConfig struct {
+	Mask        bool   `yaml:"mask" env:"PII_MASK" default:"true" usage:"mask delivery name, phone, email and address in API responses"`
+	UnmaskRole  string `yaml:"unmask_role" env:"PII_UNMASK_ROLE" default:"admin" usage:"role that sees delivery PII unmasked: reader, writer or admin"`
+	KeyringFile string `yaml:"keyring_file" env:"PII_KEYRING_FILE" usage:"keyring file with the keys delivery PII is encrypted with, empty stores it in plaintext"`
+}
+
+type DatabaseConfig struct {
+	URL         string `yaml:"url" env:"DATABASE_URL" default:"postgres://postgres:postgres@db:5432/demo_service" secret:"true" usage:"PostgreSQL connection string"`
+	AutoMigrate bool   `yaml:"auto_migrate" env:"AUTO_MIGRATE" default:"false" usage:"apply pending migrations on startup"`
+
+	MaxConns               int           `yaml:"max_conns" env:"DB_MAX_CONNS" default:"10" usage:"maximum pool size"`
+	MinConns               int           `yaml:"min_conns" env:"DB_MIN_CONNS" default:"0" usage:"minimum pool size"`
+	MinIdleConns           int           `yaml:"min_idle_conns" env:"DB_MIN_IDLE_CONNS" default:"2" usage:"minimum idle connections"`
+	MaxConnLifetime        time.Duration `yaml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME" default:"1h" usage:"close connections older than this"`
+	MaxConnIdleTime        time.Duration `yaml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME" default:"30m" usage:"close connections idle longer than this"`
+	HealthCheckPeriod      time.Duration `yaml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD" default:"1m" usage:"how often idle connections are checked"`
+	ConnectTimeout         time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT" default:"5s" usage:"connection timeout"`
+	StatementTimeout       time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT" default:"30s" usage:"PostgreSQL statement_timeout, 0 disables it"`
+	ExecMode               string        `yaml:"exec_mode" env:"DB_EXEC_MODE" default:"cache_statement" usage:"pgx query exec mode"`
+	StatementCacheCapacity int           `yaml:"statement_cache_capacity" env:"DB_STATEMENT_CACHE_CAPACITY" default:"512" usage:"prepared statements cached per connection"`
+
+	// ReplicaURLs are read-only replicas for query traffic.
+	ReplicaURLs          []string      `yaml:"replica_urls" env:"DATABASE_REPLICA_URLS" secret:"true" usage:"comma-separated read replica connection strings"`
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" env:"DB_REPLICA_MAX_LAG" default:"5s" usage:"take replicas lagging more than this out of rotation"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL" default:"5s" usage:"how often replicas are checked"`
+}
+
+type KafkaConfig struct {
+	Brokers           []string `yaml:"brokers" env:"KAFKA_BROKERS" default:"kafka:9092" usage:"comma-separated Kafka brokers"`
+	Topic             string   `yaml:"topic" env:"KAFKA_TOPIC" default:"orders" usage:"topic orders are consumed from"`
+	DLQTopic          string   `yaml:"dlq_topic" env:"KAFKA_DLQ_TOPIC" default:"orders-dlq" usage:"topic rejected messages are sent to"`
+	GroupID           string   `yaml:"group_id" env:"KAFKA_GROUP_ID" default:"order-service-group" usage:"consumer group ID"`
+	SchemaRegistryURL string   `yaml:"schema_registry_url" env:"SCHEMA_REGISTRY_URL" secret:"true" usage:"Confluent Schema Registry URL, enables Avro and Protobuf"`
+	MessageFormat     string   `yaml:"message_format" env:"MESSAGE_FORMAT" default:"json" usage:"format the producer writes: json, avro or protobuf"`
+	StrictJSON        bool     `yaml:"strict_json" env:"STRICT_JSON_KAFKA" default:"false" reload:"true" usage:"reject unknown fields and type mismatches in JSON messages"`
+
+	StallTimeout time.Duration `yaml:"stall_timeout" env:"KAFKA_STALL_TIMEOUT" default:"1m" usage:"/readyz reports the consumer as stuck when it neither reads a message nor idles for this long"`
+
+	TLS           bool   `yaml:"tls" env:"KAFKA_TLS" default:"false" usage:"connect to brokers over TLS"`
+	TLSCAFile     string `yaml:"tls_ca_file" env:"KAFKA_TLS_CA_FILE" usage:"PEM CA bundle broker certificates are verified against, empty uses the system roots"`
+	TLSCertFile   string `yaml:"tls_cert_file" env:"KAFKA_TLS_CERT_FILE" usage:"PEM client certificate for brokers that require one"`
+	TLSKeyFile    string `yaml:"tls_key_file" env:"KAFKA_TLS_KEY_FILE" usage:"PEM private key of tls_cert_file"`
+	SASLMechanism string `yaml:"sasl_mechanism" env:"KAFKA_SASL_MECHANISM" usage:"SASL mechanism: plain, scram-sha-256 or scram-sha-512, empty disables SASL"`
+	SASLUsername  string `yaml:"sasl_username" env:"KAFKA_SASL_USERNAME" usage:"SASL user name"`
+	SASLPassword  string `yaml:"sasl_password" env:"KAFKA_SASL_PASSWORD" secret:"true" usage:"SASL password"`
 }
 
-func Load() *Config {
-	cfg := &Config{
-		PostgresURL:  getEnv("DATABASE_URL", "postgres://postgres:postgres@db:5432/demo_service"),
-		KafkaBrokers: getEnv("KAFKA_BROKERS", "kafka:9092"),
+type CacheConfig struct {
+	Size int           `yaml:"size" env:"CACHE_SIZE" default:"100" reload:"true" usage:"maximum number of cached orders"`
+	TTL  time.Duration `yaml:"ttl" env:"CACHE_TTL" default:"0s" reload:"true" usage:"how long an order stays cached, 0 keeps it until evicted"`
+}
+
+type PartitionConfig struct {
+	PremakeMonths       int           `yaml:"premake_months" env:"PARTITION_PREMAKE_MONTHS" default:"3" usage:"months ahead to create partitions for"`
+	RetentionMonths     int           `yaml:"retention_months" env:"PARTITION_RETENTION_MONTHS" default:"0" usage:"full months of partitions to keep, 0 keeps all"`
+	Archive             bool          `yaml:"archive" env:"PARTITION_ARCHIVE" default:"true" usage:"move retired partitions to the archive schema instead of dropping them"`
+	MaintenanceInterval time.Duration `yaml:"maintenance_interval" env:"PARTITION_MAINTENANCE_INTERVAL" default:"1h" usage:"how often partitions are maintained, 0 disables it"`
+}
+
+// Pool is the connection pool configuration for the primary and replicas.
+func (c DatabaseConfig) Pool() db.PoolConfig {
+	return db.PoolConfig{
+		MaxConns:               int32(c.MaxConns),
+		MinConns:               int32(c.MinConns),
+		MinIdleConns:           int32(c.MinIdleConns),
+		MaxConnLifetime:        c.MaxConnLifetime,
+		MaxConnIdleTime:        c.MaxConnIdleTime,
+		HealthCheckPeriod:      c.HealthCheckPeriod,
+		ConnectTimeout:         c.ConnectTimeout,
+		StatementTimeout:       c.StatementTimeout,
+		ExecMode:               c.ExecMode,
+		StatementCacheCapacity: c.StatementCacheCapacity,
+	}
+}
+
+// Authenticator builds the authenticator for these settings; it is nil,
+// letting every request through, if authentication is disabled.
+func (c AuthConfig) Authenticator() (*auth.Authenticator, error) {
+	if !c.Enabled {
+		return nil, nil
 	}
 
-	return cfg
+	keys := make([]auth.APIKey, 0, len(c.APIKeys))
+	for _, s := range c.APIKeys {
+		key, err := auth.ParseAPIKey(s)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	var verifier *auth.JWTVerifier
+	if c.JWKSFile != "" {
+		var err error
+		verifier, err = auth.NewJWTVerifier(auth.JWTConfig{
+			JWKSFile:  c.JWKSFile,
+			Issuer:    c.JWTIssuer,
+			Audience:  c.JWTAudience,
+			RoleClaim: c.JWTRoleClaim,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return auth.New(keys, verifier), nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if val, ok := os.LookupEnv(key); ok {
-		return val
+// ServerTLS loads the HTTPS certificate and the CAs client certificates are
+// verified against. The certificate is nil, serving plain HTTP, if none is
+// configured; the CAs are nil if client certificates are not verified.
+func (c HTTPConfig) ServerTLS() (*tlsutil.CertReloader, *x509.CertPool, error) {
+	if c.TLSCertFile == "" {
+		return nil, nil, nil
+	}
+	certs, err := tlsutil.NewCertReloader(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.TLSClientCAFile == "" {
+		return certs, nil, nil
+	}
+	clientCAs, err := tlsutil.LoadCertPool(c.TLSClientCAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certs, clientCAs, nil
+}
+
+// Security builds the TLS and SASL settings of broker connections.
+func (c KafkaConfig) Security() (kafka.Security, error) {
+	var sec kafka.Security
+	if c.TLS {
+		tlsConfig, err := tlsutil.ClientConfig(c.TLSCAFile, c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return sec, err
+		}
+		sec.TLS = tlsConfig
+	}
+	mechanism, err := kafka.NewSASL(c.SASLMechanism, c.SASLUsername, c.SASLPassword)
+	if err != nil {
+		return sec, err
 	}
-	log.Printf("ENV %s not set, using default: %s", key, defaultValue)
-	return defaultValue
+	sec.SASL = mechanism
+	return sec, nil
+}
+
+// Keyring loads the PII keyring; it is nil, storing PII in plaintext, if no
+// keyring file is configured.
+func (c PIIConfig) Keyring() (*pii.Keyring, error) {
+	if c.KeyringFile == "" {
+		return nil, nil
+	}
+	return pii.LoadKeyring(c.KeyringFile)
+}
+
+// Options are the tracing.Setup options for these settings; the stdout
+// exporter writes to os.Stdout.
+func (c TracingConfig) Options() tracing.Config {
+	return tracing.Config{
+		Exporter:    c.Exporter,
+		ServiceName: c.ServiceName,
+		Endpoint:    c.OTLPEndpoint,
+		Stdout:      os.Stdout,
+	}
+}
+
+var (
+	logLevels      = []string{"debug", "info", "warn", "error"}
+	logFormats     = []string{"json", "text"}
+	traceExporters = []string{"none", "stdout", "otlp"}
+	execModes      = []string{"cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol"}
+	messageFormats = []string{"json", "avro", "protobuf"}
+	saslMechanisms = []string{"", kafka.SASLPlain, kafka.SASLScramSHA256, kafka.SASLScramSHA512}
+)
+
+// Validate reports every invalid setting, each prefixed with its YAML path.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, path, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(slices.Contains(logLevels, strings.ToLower(c.Log.Level)), "log.level", "must be one of %s", strings.Join(logLevels, ", "))
+	check(slices.Contains(logFormats, c.Log.Format), "log.format", "must be one of %s", strings.Join(logFormats, ", "))
+
+	check(slices.Contains(traceExporters, c.Tracing.Exporter), "tracing.exporter", "must be one of %s", strings.Join(traceExporters, ", "))
+	check(c.Tracing.ServiceName != "", "tracing.service_name", "must not be empty")
+
+	_, _, err := net.SplitHostPort(c.HTTP.Addr)
+	check(err == nil, "http.addr", "must be host:port, got %q", c.HTTP.Addr)
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout", "must be positive")
+	check(c.HTTP.ReadHeaderTimeout > 0, "http.read_header_timeout", "must be positive")
+	check(c.HTTP.ReadTimeout >= 0, "http.read_timeout", "must not be negative")
+	check(c.HTTP.WriteTimeout >= 0, "http.write_timeout", "must not be negative")
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout", "must not be negative")
+	check(c.HTTP.MaxBodyBytes > 0, "http.max_body_bytes", "must be positive")
+	check(c.HTTP.MaxImportBytes > 0, "http.max_import_bytes", "must be positive")
+	check(c.HTTP.RateLimitRPS >= 0, "http.rate_limit_rps", "must not be negative")
+	check(c.HTTP.RateLimitBurst > 0, "http.rate_limit_burst", "must be positive")
+	check((c.HTTP.TLSCertFile == "") == (c.HTTP.TLSKeyFile == ""), "http.tls_key_file", "must be set together with http.tls_cert_file")
+	check(c.HTTP.TLSClientCAFile == "" || c.HTTP.TLSCertFile != "", "http.tls_client_ca_file", "requires http.tls_cert_file")
+	check(c.HTTP.TLSReloadInterval > 0, "http.tls_reload_interval", "must be positive")
+
+	_, _, err = net.SplitHostPort(c.Admin.Addr)
+	check(err == nil, "admin.addr", "must be host:port, got %q", c.Admin.Addr)
+	check(c.Admin.Addr != c.HTTP.Addr, "admin.addr", "must differ from http.addr")
+	check(c.Admin.HealthTimeout > 0, "admin.health_timeout", "must be positive")
+
+	a := c.Auth
+	for _, key := range a.APIKeys {
+		_, err := auth.ParseAPIKey(key)
+		check(err == nil, "auth.api_keys", "%v", err)
+	}
+	check(!a.Enabled || len(a.APIKeys) > 0 || a.JWKSFile != "", "auth.enabled", "requires auth.api_keys or auth.jwks_file")
+	check(a.JWKSFile == "" || a.JWTRoleClaim != "", "auth.jwt_role_claim", "must not be empty")
+
+	_, err = auth.ParseRole(c.PII.UnmaskRole)
+	check(err == nil, "pii.unmask_role", "%v", err)
+
+	d := c.Database
+	check(d.URL != "", "database.url", "must not be empty")
+	check(d.MaxConns > 0, "database.max_conns", "must be positive")
+	check(d.MinConns >= 0 && d.MinConns <= d.MaxConns, "database.min_conns", "must be between 0 and max_conns")
+	check(d.MinIdleConns >= 0 && d.MinIdleConns <= d.MaxConns, "database.min_idle_conns", "must be between 0 and max_conns")
+	check(d.MaxConnLifetime > 0, "database.max_conn_lifetime", "must be positive")
+	check(d.MaxConnIdleTime > 0, "database.max_conn_idle_time", "must be positive")
+	check(d.HealthCheckPeriod > 0, "database.health_check_period", "must be positive")
+	check(d.ConnectTimeout > 0, "database.connect_timeout", "must be positive")
+	check(d.StatementTimeout >= 0, "database.statement_timeout", "must not be negative")
+	check(slices.Contains(execModes, d.ExecMode), "database.exec_mode", "must be one of %s", strings.Join(execModes, ", "))
+	check(d.StatementCacheCapacity > 0, "database.statement_cache_capacity", "must be positive")
+	check(!slices.Contains(d.ReplicaURLs, ""), "database.replica_urls", "must not contain empty URLs")
+	check(d.ReplicaMaxLag > 0, "database.replica_max_lag", "must be positive")
+	check(d.ReplicaCheckInterval > 0, "database.replica_check_interval", "must be positive")
+
+	k := c.Kafka
+	check(len(k.Brokers) > 0 && !slices.Contains(k.Brokers, ""), "kafka.brokers", "must list at least one broker")
+	check(k.Topic != "", "kafka.topic", "must not be empty")
+	check(k.DLQTopic != "", "kafka.dlq_topic", "must not be empty")
+	check(k.DLQTopic != k.Topic, "kafka.dlq_topic", "must differ from kafka.topic")
+	check(k.GroupID != "", "kafka.group_id", "must not be empty")
+	check(k.StallTimeout > 0, "kafka.stall_timeout", "must be positive")
+	check(slices.Contains(messageFormats, k.MessageFormat), "kafka.message_format", "must be one of %s", strings.Join(messageFormats, ", "))
+	check(k.MessageFormat == "json" || k.SchemaRegistryURL != "", "kafka.message_format",
+		"%s requires kafka.schema_registry_url", k.MessageFormat)
+
+	check(k.TLS || (k.TLSCAFile == "" && k.TLSCertFile == ""), "kafka.tls", "must be enabled to use kafka.tls_ca_file and kafka.tls_cert_file")
+	check((k.TLSCertFile == "") == (k.TLSKeyFile == ""), "kafka.tls_key_file", "must be set together with kafka.tls_cert_file")
+	check(slices.Contains(saslMechanisms, k.SASLMechanism), "kafka.sasl_mechanism", "must be one of %s or empty", strings.Join(saslMechanisms[1:], ", "))
+	check(k.SASLMechanism == "" || k.SASLUsername != "", "kafka.sasl_username", "is required by kafka.sasl_mechanism")
+
+	check(c.Cache.Size > 0, "cache.size", "must be positive")
+	check(c.Cache.TTL >= 0, "cache.ttl", "must not be negative")
+
+	p := c.Partition
+	check(p.PremakeMonths >= 0, "partition.premake_months", "must not be negative")
+	check(p.RetentionMonths >= 0, "partition.retention_months", "must not be negative")
+	check(p.MaintenanceInterval >= 0, "partition.maintenance_interval", "must not be negative")
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/tlsutil/tlstest"
+)
+
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(new(bytes.Buffer))
+	return Load(fs, args)
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.HTTP.Addr != ":8081" || cfg.Admin.Addr != ":8082" || cfg.Kafka.Topic != "orders" || cfg.Kafka.DLQTopic != "orders-dlq" {
+		t.Errorf("unexpected defaults: %+v %+v %+v", cfg.HTTP, cfg.Admin, cfg.Kafka)
+	}
+	if !slices.Equal(cfg.Kafka.Brokers, []string{"kafka:9092"}) {
+		t.Errorf("Brokers = %v", cfg.Kafka.Brokers)
+	}
+	if cfg.Cache.Size != 100 || cfg.Database.ReplicaMaxLag != 5*time.Second || !cfg.Partition.Archive {
+		t.Errorf("unexpected defaults: %+v %+v %+v", cfg.Cache, cfg.Database, cfg.Partition)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, `
+http:
+  addr: ":9000"
+kafka:
+  topic: from-file
+  group_id: file-group
+  brokers: [a:9092, b:9092]
+cache:
+  size: 5
+`)
+	t.Setenv(FileEnv, path)
+	t.Setenv("KAFKA_TOPIC", "from-env")
+	t.Setenv("CACHE_SIZE", "7")
+
+	cfg, err := load(t, "--cache.size=9", "--kafka.strict-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.HTTP.Addr != ":9000" {
+		t.Errorf("file value not applied: Addr = %q", cfg.HTTP.Addr)
+	}
+	if cfg.Kafka.GroupID != "file-group" || !slices.Equal(cfg.Kafka.Brokers, []string{"a:9092", "b:9092"}) {
+		t.Errorf("file values not applied: %+v", cfg.Kafka)
+	}
+	if cfg.Kafka.Topic != "from-env" {
+		t.Errorf("env should override file: Topic = %q", cfg.Kafka.Topic)
+	}
+	if cfg.Cache.Size != 9 {
+		t.Errorf("flag should override env: Size = %d", cfg.Cache.Size)
+	}
+	if !cfg.Kafka.StrictJSON {
+		t.Error("bool flag without value should set true")
+	}
+	if cfg.Kafka.DLQTopic != "orders-dlq" {
+		t.Errorf("default should stay: DLQTopic = %q", cfg.Kafka.DLQTopic)
+	}
+}
+
+func TestLoad_ConfigFlagOverridesEnv(t *testing.T) {
+	t.Setenv(FileEnv, filepath.Join(t.TempDir(), "missing.yaml"))
+	path := writeFile(t, "cache:\n  size: 3\n")
+
+	cfg, err := load(t, "--config", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Cache.Size != 3 {
+		t.Errorf("Size = %d, want 3", cfg.Cache.Size)
+	}
+}
+
+func TestLoad_UnknownFileKey(t *testing.T) {
+	path := writeFile(t, "kafka:\n  topik: orders\n")
+
+	cfg, err := load(t, "--config", path)
+	if err == nil || !strings.Contains(err.Error(), "topik") {
+		t.Fatalf("expected an error about the unknown key, got %v", err)
+	}
+	if cfg != nil {
+		t.Error("config should be nil when the file cannot be read")
+	}
+}
+
+func TestLoad_InvalidValues(t *testing.T) {
+	t.Setenv("CACHE_SIZE", "many")
+
+	_, err := load(t)
+	if err == nil || !strings.Contains(err.Error(), "CACHE_SIZE") {
+		t.Fatalf("expected env error, got %v", err)
+	}
+
+	if _, err := load(t, "--database.max-conns=x"); err == nil {
+		t.Error("expected flag parse error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "")
+	t.Setenv("MESSAGE_FORMAT", "avro")
+
+	cfg, err := load(t, "--http.addr=8081", "--admin.addr=localhost", "--http.max-body-bytes=0", "--http.rate-limit-rps=-1",
+		"--cache.size=0", "--kafka.dlq-topic=orders", "--kafka.stall-timeout=0s", "--database.min-conns=20")
+	if cfg == nil {
+		t.Fatal("config should be returned along with validation errors")
+	}
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	for _, want := range []string{
+		"http.addr",
+		"admin.addr",
+		"http.max_body_bytes",
+		"http.rate_limit_rps",
+		"cache.size",
+		"kafka.brokers",
+		"kafka.dlq_topic: must differ",
+		"kafka.stall_timeout",
+		"kafka.message_format: avro requires kafka.schema_registry_url",
+		"database.min_conns",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestValidate_Auth(t *testing.T) {
+	if _, err := load(t, "--auth.enabled"); err == nil || !strings.Contains(err.Error(), "auth.enabled") {
+		t.Errorf("auth without keys or JWKS: err = %v", err)
+	}
+	if _, err := load(t, "--auth.enabled", "--auth.api-keys=ci:root:abc"); err == nil || !strings.Contains(err.Error(), "auth.api_keys") {
+		t.Errorf("invalid API key: err = %v", err)
+	}
+
+	hash := strings.Repeat("0", 64)
+	cfg, err := load(t, "--auth.enabled", "--auth.api-keys=ci:writer:"+hash+",ops:admin:"+hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, err := cfg.Auth.Authenticator(); err != nil || a == nil {
+		t.Errorf("Authenticator() = %v, %v", a, err)
+	}
+}
+
+func TestValidate_TLS(t *testing.T) {
+	for args, want := range map[string]string{
+		"--http.tls-cert-file=server.crt":         "http.tls_key_file",
+		"--http.tls-client-ca-file=clients.crt":   "http.tls_client_ca_file",
+		"--kafka.tls-ca-file=ca.crt":              "kafka.tls",
+		"--kafka.sasl-mechanism=gssapi":           "kafka.sasl_mechanism",
+		"--kafka.sasl-mechanism=scram-sha-512":    "kafka.sasl_username",
+		"--kafka.tls --kafka.tls-cert-file=c.crt": "kafka.tls_key_file",
+	} {
+		if _, err := load(t, strings.Fields(args)...); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want it to mention %s", args, err, want)
+		}
+	}
+
+	ca := tlstest.NewCA(t, "ca")
+	server, client := ca.Server(t, "server"), ca.Client(t, "client")
+	cfg, err := load(t,
+		"--http.tls-cert-file="+server.CertFile, "--http.tls-key-file="+server.KeyFile, "--http.tls-client-ca-file="+ca.CertFile,
+		"--kafka.tls", "--kafka.tls-ca-file="+ca.CertFile, "--kafka.tls-cert-file="+client.CertFile, "--kafka.tls-key-file="+client.KeyFile,
+		"--kafka.sasl-mechanism=scram-sha-256", "--kafka.sasl-username=svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certs, clientCAs, err := cfg.HTTP.ServerTLS(); err != nil || certs == nil || clientCAs == nil {
+		t.Errorf("ServerTLS() = %v, %v, %v", certs, clientCAs, err)
+	}
+	sec, err := cfg.Kafka.Security()
+	if err != nil || sec.TLS == nil || len(sec.TLS.Certificates) != 1 || sec.SASL == nil {
+		t.Errorf("Security() = %+v, %v", sec, err)
+	}
+}
+
+func TestWriteYAML_RedactsSecretsAndRoundTrips(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://app:s3cret@db:5432/orders")
+	t.Setenv("DATABASE_REPLICA_URLS", "host=replica password=hunter2")
+
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.WriteYAML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, secret := range []string{"s3cret", "hunter2"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output leaks %q:\n%s", secret, out)
+		}
+	}
+	for _, want := range []string{"postgres://app:REDACTED@db:5432/orders", "replica_urls: [REDACTED]", "replica_max_lag: 5s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	// Apart from the secrets, the output is a valid config file.
+	os.Unsetenv("DATABASE_URL")
+	os.Unsetenv("DATABASE_REPLICA_URLS")
+	printed, err := load(t, "--config", writeFile(t, out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if printed.Database.StatementTimeout != cfg.Database.StatementTimeout || printed.Kafka.GroupID != cfg.Kafka.GroupID {
+		t.Errorf("round trip mismatch: %+v vs %+v", printed, cfg)
+	}
+}
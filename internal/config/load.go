@@ -0,0 +1,290 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file when --config is not given.
+const FileEnv = "CONFIG_FILE"
+
+const redacted = "REDACTED"
+
+// field is one leaf setting of Config.
+type field struct {
+	path   string // YAML path, e.g. kafka.dlq_topic
+	env    string
+	def    string
+	usage  string
+	secret bool
+	reload bool
+	value  reflect.Value
+}
+
+// flagName turns a YAML path into a flag name: kafka.dlq_topic becomes
+// kafka.dlq-topic.
+func (f *field) flagName() string {
+	return strings.ReplaceAll(f.path, "_", "-")
+}
+
+// Load builds the configuration from, in increasing priority, the defaults
+// in the struct tags, the YAML file named by --config or CONFIG_FILE,
+// environment variables and command-line flags. It registers its flags on
+// fs before parsing args, so commands can add flags of their own.
+//
+// The config is returned even when it fails validation, so that it can
+// still be printed; it is nil only if args or the file could not be read.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "")
+
+	configPath := fs.String("config", "", "YAML config file (env "+FileEnv+")")
+	flagValues := make([]*flagValue, len(fields))
+	for i, f := range fields {
+		fv := &flagValue{typ: f.value.Type(), raw: f.def}
+		if f.secret && f.def != "" {
+			fv.raw = redact(f.def)
+		}
+		usage := f.usage
+		if f.env != "" {
+			usage += " (env " + f.env + ")"
+		}
+		fs.Var(fv, f.flagName(), usage)
+		flagValues[i] = fv
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		if err := setValue(f.value, f.def); err != nil {
+			return nil, fmt.Errorf("default of %s: %w", f.path, err)
+		}
+	}
+
+	path := *configPath
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if val, ok := os.LookupEnv(f.env); ok {
+			if err := setValue(f.value, val); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", f.env, err))
+			}
+		}
+	}
+
+	for i, fv := range flagValues {
+		if fv.set {
+			// The value was already checked by flagValue.Set.
+			_ = setValue(fields[i].value, fv.raw)
+		}
+	}
+
+	if len(errs) > 0 {
+		return cfg, errors.Join(errs...)
+	}
+	return cfg, cfg.Validate()
+}
+
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func collectFields(v reflect.Value, prefix string) []*field {
+	var fields []*field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		path := prefix + sf.Tag.Get("yaml")
+
+		if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+			fields = append(fields, collectFields(v.Field(i), path+".")...)
+			continue
+		}
+
+		fields = append(fields, &field{
+			path:   path,
+			env:    sf.Tag.Get("env"),
+			def:    sf.Tag.Get("default"),
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret") == "true",
+			reload: sf.Tag.Get("reload") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return fields
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	stringsType  = reflect.TypeOf([]string(nil))
+)
+
+// setValue parses s into v. Lists are comma-separated; empty entries are
+// dropped.
+func setValue(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		if s == "" {
+			v.SetInt(0)
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Type() == stringsType:
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		if s == "" {
+			v.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		if s == "" {
+			v.SetInt(0)
+			return nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// flagValue holds the raw text of a config flag; it is applied after the
+// file and the environment so that flags take precedence.
+type flagValue struct {
+	typ reflect.Type
+	raw string
+	set bool
+}
+
+func (v *flagValue) String() string {
+	return v.raw
+}
+
+func (v *flagValue) Set(s string) error {
+	if err := setValue(reflect.New(v.typ).Elem(), s); err != nil {
+		return err
+	}
+	v.raw = s
+	v.set = true
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.typ != nil && v.typ.Kind() == reflect.Bool
+}
+
+// WriteYAML writes the effective configuration in the config file format,
+// with secrets redacted. Passwords in URLs are masked and other secrets are
+// replaced entirely.
+func (c *Config) WriteYAML(w io.Writer) error {
+	node := yamlNode(reflect.ValueOf(c).Elem(), reflect.StructField{})
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func yamlNode(v reflect.Value, sf reflect.StructField) *yaml.Node {
+	secret := sf.Tag.Get("secret") == "true"
+	scalar := func(s string, tag string) *yaml.Node {
+		if secret && s != "" {
+			s = redact(s)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: s}
+	}
+
+	switch {
+	case v.Type() == durationType:
+		return scalar(time.Duration(v.Int()).String(), "!!str")
+	case v.Kind() == reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: f.Tag.Get("yaml")},
+				yamlNode(v.Field(i), f))
+		}
+		return node
+	case v.Kind() == reflect.Slice:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < v.Len(); i++ {
+			node.Content = append(node.Content, yamlNode(v.Index(i), sf))
+		}
+		return node
+	case v.Kind() == reflect.Bool:
+		return scalar(strconv.FormatBool(v.Bool()), "!!bool")
+	case v.Kind() == reflect.Int:
+		return scalar(strconv.FormatInt(v.Int(), 10), "!!int")
+	default:
+		return scalar(v.String(), "!!str")
+	}
+}
+
+func redact(s string) string {
+	u, err := url.Parse(s)
+	if err == nil && u.Scheme != "" && u.Host != "" {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		return u.String()
+	}
+	return redacted
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/metrics"
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+// ActionReload is the audit log action of a reload that changed settings.
+const ActionReload = "config.reload"
+
+// Reloader re-reads the configuration at runtime and hands the settings
+// tagged reload:"true" to the running components. Other settings are only
+// read at startup: a reload reports them as requiring a restart and keeps
+// their old values.
+type Reloader struct {
+	mu       sync.Mutex
+	load     func() (*Config, error)
+	current  atomic.Pointer[Config]
+	version  atomic.Uint64
+	handlers []func(*Config)
+	recorder audit.Recorder
+}
+
+// ReloadResult lists the settings, by YAML path, that a reload changed.
+type ReloadResult struct {
+	Version         uint64   `json:"version"`
+	Changed         []string `json:"changed"`
+	RequiresRestart []string `json:"requires_restart"`
+}
+
+// NewReloader starts at version 1 with cfg; load is called on every reload
+// and should read the same sources as at startup.
+func NewReloader(cfg *Config, load func() (*Config, error)) *Reloader {
+	r := &Reloader{load: load}
+	r.current.Store(cfg)
+	r.version.Store(1)
+	metrics.ConfigVersion.Set(1)
+	return r
+}
+
+// Current returns the active configuration. It must not be modified.
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+func (r *Reloader) Version() uint64 {
+	return r.version.Load()
+}
+
+// OnReload registers fn to be called with the new configuration after
+// every reload that changes a reloadable setting. Handlers run one at a
+// time, in the order they were registered.
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+// AuditTo makes every reload that changes a reloadable setting record an
+// entry with the old and new values in rec, attributed to the actor in the
+// context passed to Reload. Secret settings are redacted as in WriteYAML.
+func (r *Reloader) AuditTo(rec audit.Recorder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recorder = rec
+}
+
+// Reload loads the configuration again and applies the reloadable settings
+// that changed. If the new configuration is invalid nothing is applied.
+func (r *Reloader) Reload(ctx context.Context) (ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		metrics.ConfigReloadsTotal.WithLabelValues("failure").Inc()
+		return ReloadResult{Version: r.version.Load()}, err
+	}
+
+	cur := r.current.Load()
+	merged := *cur
+	curFields := collectFields(reflect.ValueOf(cur).Elem(), "")
+	nextFields := collectFields(reflect.ValueOf(next).Elem(), "")
+	mergedFields := collectFields(reflect.ValueOf(&merged).Elem(), "")
+
+	res := ReloadResult{Changed: []string{}, RequiresRestart: []string{}}
+	diff := make(map[string]models.FieldChange)
+	for i, f := range curFields {
+		if reflect.DeepEqual(f.value.Interface(), nextFields[i].value.Interface()) {
+			continue
+		}
+		if f.reload {
+			mergedFields[i].value.Set(nextFields[i].value)
+			res.Changed = append(res.Changed, f.path)
+			diff[f.path] = models.FieldChange{Before: auditValue(f), After: auditValue(nextFields[i])}
+		} else {
+			res.RequiresRestart = append(res.RequiresRestart, f.path)
+		}
+	}
+
+	metrics.ConfigReloadsTotal.WithLabelValues("success").Inc()
+	if len(res.RequiresRestart) > 0 {
+		slog.Warn("Config reload: some settings only take effect after a restart", "settings", res.RequiresRestart)
+	}
+	if len(res.Changed) == 0 {
+		res.Version = r.version.Load()
+		slog.Info("Config reload: no reloadable settings changed", "version", res.Version)
+		return res, nil
+	}
+
+	r.current.Store(&merged)
+	res.Version = r.version.Add(1)
+	metrics.ConfigVersion.Set(float64(res.Version))
+	for _, fn := range r.handlers {
+		fn(&merged)
+	}
+
+	slog.InfoContext(ctx, "Config reloaded", "version", res.Version, "changed", res.Changed)
+	if r.recorder != nil {
+		// The settings are applied already, so a failure to record them
+		// does not fail the reload.
+		if err := r.recorder.Record(ctx, models.AuditEntry{Action: ActionReload, Diff: diff}); err != nil {
+			slog.ErrorContext(ctx, "Failed to record config reload in the audit log", "error", err)
+		}
+	}
+	return res, nil
+}
+
+// auditValue is the value of f as recorded in the audit log.
+func auditValue(f *field) any {
+	if f.secret {
+		return redacted
+	}
+	if d, ok := f.value.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return f.value.Interface()
+}
+
+// WatchSignals reloads the configuration on SIGHUP until ctx is done.
+// Reloads are attributed to the SIGHUP signal in the audit log.
+func (r *Reloader) WatchSignals(ctx context.Context) {
+	ctx = audit.WithActor(ctx, audit.Actor{Name: "SIGHUP", Source: audit.SourceSignal})
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			if _, err := r.Reload(ctx); err != nil {
+				slog.ErrorContext(ctx, "Config reload failed", "version", r.Version(), "error", err)
+			}
+		}
+	}
+}
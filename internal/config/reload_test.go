@@ -0,0 +1,117 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+type recorder struct {
+	entries []models.AuditEntry
+	actors  []audit.Actor
+}
+
+func (r *recorder) Record(ctx context.Context, entry models.AuditEntry) error {
+	r.entries = append(r.entries, entry)
+	r.actors = append(r.actors, audit.ActorFrom(ctx))
+	return nil
+}
+
+func TestReloader(t *testing.T) {
+	initial, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := *initial
+	r := NewReloader(initial, func() (*Config, error) {
+		cfg := next
+		return &cfg, nil
+	})
+
+	var applied []*Config
+	r.OnReload(func(c *Config) { applied = append(applied, c) })
+	rec := &recorder{}
+	r.AuditTo(rec)
+
+	// Nothing changed: same version, handlers not called.
+	res, err := r.Reload(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Version != 1 || len(res.Changed) != 0 || len(applied) != 0 {
+		t.Fatalf("unexpected result for unchanged config: %+v, %d handler calls", res, len(applied))
+	}
+
+	next.Cache.Size = 500
+	next.Cache.TTL = time.Minute
+	next.Log.Level = "debug"
+	next.HTTP.Addr = ":9999"
+	res, err = r.Reload(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Version != 2 || r.Version() != 2 {
+		t.Errorf("version = %d / %d, want 2", res.Version, r.Version())
+	}
+	if want := []string{"log.level", "cache.size", "cache.ttl"}; !slices.Equal(res.Changed, want) {
+		t.Errorf("Changed = %v, want %v", res.Changed, want)
+	}
+	if want := []string{"http.addr"}; !slices.Equal(res.RequiresRestart, want) {
+		t.Errorf("RequiresRestart = %v, want %v", res.RequiresRestart, want)
+	}
+
+	cur := r.Current()
+	if cur.Cache.Size != 500 || cur.Cache.TTL != time.Minute || cur.Log.Level != "debug" {
+		t.Errorf("reloadable settings not applied: %+v %+v", cur.Cache, cur.Log)
+	}
+	if cur.HTTP.Addr != ":8081" {
+		t.Errorf("http.addr should keep its startup value, got %q", cur.HTTP.Addr)
+	}
+	if len(applied) != 1 || applied[0] != cur {
+		t.Errorf("handler should be called once with the new config")
+	}
+	if initial.Cache.Size != 100 {
+		t.Error("the previous config must not be modified")
+	}
+
+	if len(rec.entries) != 1 {
+		t.Fatalf("recorded %d audit entries, want 1 for the reload that changed settings", len(rec.entries))
+	}
+	entry := rec.entries[0]
+	if entry.Action != ActionReload || len(entry.Diff) != 3 {
+		t.Errorf("audit entry = %+v", entry)
+	}
+	if got := entry.Diff["cache.ttl"]; got.Before != "0s" || got.After != "1m0s" {
+		t.Errorf("cache.ttl change = %+v", got)
+	}
+	if got := entry.Diff["cache.size"]; got.Before != 100 || got.After != 500 {
+		t.Errorf("cache.size change = %+v", got)
+	}
+}
+
+func TestReloader_InvalidConfigKeepsCurrent(t *testing.T) {
+	initial, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReloader(initial, func() (*Config, error) {
+		return nil, errors.New("cache.size: must be positive")
+	})
+	r.OnReload(func(*Config) { t.Error("handler must not run for an invalid config") })
+
+	res, err := r.Reload(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if res.Version != 1 || r.Current() != initial {
+		t.Errorf("active config changed after a failed reload")
+	}
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sonni-a/wb-service/internal/metrics"
+)
+
+// replicaLagQuery reports replay lag in seconds. A replica that has replayed
+// everything it received counts as caught up even if the primary has been
+// idle for a while.
+const replicaLagQuery = `
+SELECT CASE
+    WHEN NOT pg_is_in_recovery() THEN 0
+    WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+    ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// Cluster is the primary pool plus optional read replicas. Reads go to
+// replicas that answer and lag behind the primary by less than maxLag;
+// when none qualifies they go to the primary.
+type Cluster struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+}
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// NewCluster creates pools for the replica URLs, configured like the
+// primary, and checks them once. An unreachable replica is not an error; it
+// simply starts out of rotation.
+func NewCluster(primary *pgxpool.Pool, replicaURLs []string, poolCfg PoolConfig, maxLag time.Duration) (*Cluster, error) {
+	c := &Cluster{primary: primary, maxLag: maxLag}
+
+	for _, url := range replicaURLs {
+		cfg, err := ParsePoolConfig(url, poolCfg)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("replica: %w", err)
+		}
+
+		pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to create replica pool: %w", err)
+		}
+
+		name := net.JoinHostPort(cfg.ConnConfig.Host, strconv.Itoa(int(cfg.ConnConfig.Port)))
+		c.replicas = append(c.replicas, &replica{name: name, pool: pool})
+		metrics.DBPools.Add("replica "+name, pool)
+	}
+
+	c.checkReplicas(context.Background())
+	return c, nil
+}
+
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+// MaxLag is how far behind the primary a replica may be and still serve reads.
+func (c *Cluster) MaxLag() time.Duration {
+	return c.maxLag
+}
+
+// Reader returns the next healthy replica in round-robin order, or the
+// primary if there is none.
+func (c *Cluster) Reader() *pgxpool.Pool {
+	n := uint64(len(c.replicas))
+	if n == 0 {
+		return c.primary
+	}
+
+	start := c.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		r := c.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return c.primary
+}
+
+// MarkUnhealthy takes a replica out of rotation until the next successful
+// health check. Passing the primary is a no-op.
+func (c *Cluster) MarkUnhealthy(pool *pgxpool.Pool) {
+	for _, r := range c.replicas {
+		if r.pool == pool {
+			c.setHealthy(r, false, "query failed")
+			return
+		}
+	}
+}
+
+// RunHealthChecks re-checks replicas every interval until ctx is done.
+func (c *Cluster) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.checkReplicas(ctx)
+		}
+	}
+}
+
+// Close closes the replica pools. The primary is owned by the caller.
+func (c *Cluster) Close() {
+	for _, r := range c.replicas {
+		metrics.DBPools.Remove("replica " + r.name)
+		r.pool.Close()
+	}
+}
+
+func (c *Cluster) checkReplicas(ctx context.Context) {
+	for _, r := range c.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+
+		var lagSeconds float64
+		err := r.pool.QueryRow(checkCtx, replicaLagQuery).Scan(&lagSeconds)
+		cancel()
+
+		lag := time.Duration(lagSeconds * float64(time.Second))
+		switch {
+		case err != nil:
+			c.setHealthy(r, false, err.Error())
+		case lag > c.maxLag:
+			c.setHealthy(r, false, fmt.Sprintf("replication lag %s exceeds %s", lag.Round(time.Millisecond), c.maxLag))
+		default:
+			c.setHealthy(r, true, "")
+		}
+	}
+}
+
+func (c *Cluster) setHealthy(r *replica, healthy bool, reason string) {
+	if r.healthy.Swap(healthy) != healthy {
+		if healthy {
+			slog.Info("Read replica is in rotation", "replica", r.name)
+		} else {
+			slog.Warn("Read replica taken out of rotation", "replica", r.name, "reason", reason)
+		}
+	}
+
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	metrics.DBReplicaHealthy.WithLabelValues(r.name).Set(value)
+}
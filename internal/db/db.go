@@ -3,23 +3,121 @@ package db
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewPool(dbURL string) (*pgxpool.Pool, error) {
+// PoolConfig tunes a connection pool. Zero values keep the pgx defaults,
+// except ConnectTimeout, which also bounds the initial ping.
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MinIdleConns      int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration
+	// StatementTimeout is set as the statement_timeout of every connection,
+	// so PostgreSQL cancels any single query running longer than that.
+	StatementTimeout time.Duration
+	// ExecMode is one of cache_statement (the default), cache_describe,
+	// describe_exec, exec or simple_protocol. The last three suit
+	// PgBouncer in transaction mode, which breaks prepared statements.
+	ExecMode string
+	// StatementCacheCapacity is the number of prepared statements or
+	// descriptions cached per connection.
+	StatementCacheCapacity int
+}
+
+var execModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+func NewPool(dbURL string, cfg PoolConfig) (*pgxpool.Pool, error) {
 	if dbURL == "" {
 		return nil, fmt.Errorf("empty database URL")
 	}
 
-	pool, err := pgxpool.New(context.Background(), dbURL)
+	poolCfg, err := ParsePoolConfig(dbURL, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create DB pool: %w", err)
 	}
 
-	if err = pool.Ping(context.Background()); err != nil {
+	ctx := context.Background()
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+	}
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping DB: %w", err)
 	}
 
 	return pool, nil
 }
+
+// ParsePoolConfig parses dbURL and applies cfg on top of it.
+func ParsePoolConfig(dbURL string, cfg PoolConfig) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = cfg.MinConns
+	}
+	if cfg.MinIdleConns > 0 {
+		poolCfg.MinIdleConns = cfg.MinIdleConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	if poolCfg.MinConns > poolCfg.MaxConns || poolCfg.MinIdleConns > poolCfg.MaxConns {
+		return nil, fmt.Errorf("min connections (%d) and min idle connections (%d) must not exceed max connections (%d)",
+			poolCfg.MinConns, poolCfg.MinIdleConns, poolCfg.MaxConns)
+	}
+
+	conn := poolCfg.ConnConfig
+	conn.Tracer = QueryTracer{}
+	if cfg.ConnectTimeout > 0 {
+		conn.ConnectTimeout = cfg.ConnectTimeout
+	}
+	if cfg.StatementTimeout > 0 {
+		conn.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.ExecMode != "" {
+		mode, ok := execModes[cfg.ExecMode]
+		if !ok {
+			return nil, fmt.Errorf("unknown exec mode %q", cfg.ExecMode)
+		}
+		conn.DefaultQueryExecMode = mode
+	}
+	if cfg.StatementCacheCapacity > 0 {
+		conn.StatementCacheCapacity = cfg.StatementCacheCapacity
+		conn.DescriptionCacheCapacity = cfg.StatementCacheCapacity
+	}
+
+	return poolCfg, nil
+}
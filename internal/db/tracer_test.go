@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryTracer(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+
+	var tracer QueryTracer
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "  select * from orders where order_uid = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "INSERT INTO orders VALUES ($1)"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("boom")})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: pgx.ErrNoRows})
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	if spans[0].Name() != "SELECT" || spans[1].Name() != "INSERT" {
+		t.Errorf("span names = %q, %q", spans[0].Name(), spans[1].Name())
+	}
+	if spans[0].Status().Code == codes.Error || spans[2].Status().Code == codes.Error {
+		t.Error("successful queries and ErrNoRows should not mark the span as failed")
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Error("failed query should mark the span as failed")
+	}
+}
+
+func TestParsePoolConfig_InstallsTracer(t *testing.T) {
+	cfg, err := ParsePoolConfig(testURL, PoolConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.ConnConfig.Tracer.(QueryTracer); !ok {
+		t.Errorf("Tracer = %T, want QueryTracer", cfg.ConnConfig.Tracer)
+	}
+}
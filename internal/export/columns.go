@@ -0,0 +1,111 @@
+package export
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt32
+	kindInt64
+	kindTime
+)
+
+// column describes one field of the flattened "one row per item" layout
+// shared by the CSV and Parquet writers.
+type column struct {
+	name  string
+	kind  columnKind
+	value func(o *models.Order, i *models.Item) any
+}
+
+var flatColumns = []column{
+	{"order_uid", kindString, func(o *models.Order, _ *models.Item) any { return o.OrderUID }},
+	{"track_number", kindString, func(o *models.Order, _ *models.Item) any { return o.TrackNumber }},
+	{"entry", kindString, func(o *models.Order, _ *models.Item) any { return o.Entry }},
+	{"locale", kindString, func(o *models.Order, _ *models.Item) any { return o.Locale }},
+	{"internal_signature", kindString, func(o *models.Order, _ *models.Item) any { return deref(o.InternalSignature) }},
+	{"customer_id", kindString, func(o *models.Order, _ *models.Item) any { return o.CustomerID }},
+	{"delivery_service", kindString, func(o *models.Order, _ *models.Item) any { return o.DeliveryService }},
+	{"shardkey", kindString, func(o *models.Order, _ *models.Item) any { return o.ShardKey }},
+	{"sm_id", kindInt32, func(o *models.Order, _ *models.Item) any { return int32(o.SmID) }},
+	{"date_created", kindTime, func(o *models.Order, _ *models.Item) any { return o.DateCreated }},
+	{"oof_shard", kindString, func(o *models.Order, _ *models.Item) any { return o.OofShard }},
+
+	{"delivery_name", kindString, func(o *models.Order, _ *models.Item) any { return o.Delivery.Name }},
+	{"delivery_phone", kindString, func(o *models.Order, _ *models.Item) any { return o.Delivery.Phone }},
+	{"delivery_zip", kindString, func(o *models.Order, _ *models.Item) any { return o.Delivery.Zip }},
+	{"delivery_city", kindString, func(o *models.Order, _ *models.Item) any { return o.Delivery.City }},
+	{"delivery_address", kindString, func(o *models.Order, _ *models.Item) any { return o.Delivery.Address }},
+	{"delivery_region", kindString, func(o *models.Order, _ *models.Item) any { return o.Delivery.Region }},
+	{"delivery_email", kindString, func(o *models.Order, _ *models.Item) any { return o.Delivery.Email }},
+
+	{"payment_transaction", kindString, func(o *models.Order, _ *models.Item) any { return o.Payment.Transaction }},
+	{"payment_request_id", kindString, func(o *models.Order, _ *models.Item) any { return deref(o.Payment.RequestID) }},
+	{"payment_currency", kindString, func(o *models.Order, _ *models.Item) any { return o.Payment.Currency }},
+	{"payment_provider", kindString, func(o *models.Order, _ *models.Item) any { return o.Payment.Provider }},
+	{"payment_amount", kindInt32, func(o *models.Order, _ *models.Item) any { return int32(o.Payment.Amount) }},
+	{"payment_dt", kindInt64, func(o *models.Order, _ *models.Item) any { return o.Payment.PaymentDt }},
+	{"payment_bank", kindString, func(o *models.Order, _ *models.Item) any { return o.Payment.Bank }},
+	{"payment_delivery_cost", kindInt32, func(o *models.Order, _ *models.Item) any { return int32(o.Payment.DeliveryCost) }},
+	{"payment_goods_total", kindInt32, func(o *models.Order, _ *models.Item) any { return int32(o.Payment.GoodsTotal) }},
+	{"payment_custom_fee", kindInt32, func(o *models.Order, _ *models.Item) any { return int32(o.Payment.CustomFee) }},
+
+	{"item_chrt_id", kindInt64, func(_ *models.Order, i *models.Item) any { return i.ChrtID }},
+	{"item_track_number", kindString, func(_ *models.Order, i *models.Item) any { return i.TrackNumber }},
+	{"item_price", kindInt32, func(_ *models.Order, i *models.Item) any { return int32(i.Price) }},
+	{"item_rid", kindString, func(_ *models.Order, i *models.Item) any { return i.RID }},
+	{"item_name", kindString, func(_ *models.Order, i *models.Item) any { return i.Name }},
+	{"item_sale", kindInt32, func(_ *models.Order, i *models.Item) any { return int32(i.Sale) }},
+	{"item_size", kindString, func(_ *models.Order, i *models.Item) any { return i.Size }},
+	{"item_total_price", kindInt32, func(_ *models.Order, i *models.Item) any { return int32(i.TotalPrice) }},
+	{"item_nm_id", kindInt64, func(_ *models.Order, i *models.Item) any { return i.NmID }},
+	{"item_brand", kindString, func(_ *models.Order, i *models.Item) any { return i.Brand }},
+	{"item_status", kindInt32, func(_ *models.Order, i *models.Item) any { return int32(i.Status) }},
+}
+
+// flatten returns one row per item; orders without items still produce
+// a single row with empty item columns.
+func flatten(o *models.Order) [][]any {
+	items := o.Items
+	if len(items) == 0 {
+		items = []models.Item{{}}
+	}
+
+	rows := make([][]any, 0, len(items))
+	for idx := range items {
+		row := make([]any, len(flatColumns))
+		for c, col := range flatColumns {
+			row[c] = col.value(o, &items[idx])
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int32:
+		return strconv.FormatInt(int64(val), 10)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case time.Time:
+		return val.UTC().Format(time.RFC3339)
+	default:
+		return ""
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
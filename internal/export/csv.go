@@ -0,0 +1,46 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+type csvWriter struct {
+	w   *csv.Writer
+	buf []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{
+		w:   csv.NewWriter(w),
+		buf: make([]string, len(flatColumns)),
+	}
+
+	for i, col := range flatColumns {
+		cw.buf[i] = col.name
+	}
+	if err := cw.w.Write(cw.buf); err != nil {
+		return nil, err
+	}
+
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(order *models.Order) error {
+	for _, row := range flatten(order) {
+		for i, v := range row {
+			cw.buf[i] = formatValue(v)
+		}
+		if err := cw.w.Write(cw.buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
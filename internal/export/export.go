@@ -0,0 +1,111 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatCSV, FormatNDJSON, FormatParquet:
+		return f, nil
+	case "":
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", s)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ParseTime accepts either an RFC 3339 timestamp or a plain YYYY-MM-DD date.
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339 or YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// Writer encodes orders one by one into an underlying stream.
+// Close flushes buffered data but does not close the underlying stream.
+type Writer interface {
+	Write(order *models.Order) error
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatParquet:
+		return newParquetWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+type OrderStreamer interface {
+	StreamOrders(ctx context.Context, filter repository.OrderFilter, fn func(*models.Order) error) error
+}
+
+// Run streams every order matching filter from src into out in the given format
+// and returns the number of exported orders.
+func Run(ctx context.Context, src OrderStreamer, filter repository.OrderFilter, format Format, out io.Writer) (int, error) {
+	w, err := NewWriter(format, out)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = src.StreamOrders(ctx, filter, func(order *models.Order) error {
+		if err := w.Write(order); err != nil {
+			return fmt.Errorf("write order %s: %w", order.OrderUID, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := w.Close(); err != nil {
+		return count, fmt.Errorf("finish export: %w", err)
+	}
+	return count, nil
+}
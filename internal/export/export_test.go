@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+type fakeStreamer struct {
+	orders []*models.Order
+}
+
+func (f *fakeStreamer) StreamOrders(_ context.Context, _ repository.OrderFilter, fn func(*models.Order) error) error {
+	for _, o := range f.orders {
+		if err := fn(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testOrders() []*models.Order {
+	return []*models.Order{
+		{
+			OrderUID:    "a",
+			TrackNumber: "TRACK-A",
+			DateCreated: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Items: []models.Item{
+				{OrderUID: "a", ChrtID: 1, Name: "first", Price: 10},
+				{OrderUID: "a", ChrtID: 2, Name: "second", Price: 20},
+			},
+		},
+		{
+			OrderUID:    "b",
+			TrackNumber: "TRACK-B",
+			DateCreated: time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+			Items: []models.Item{
+				{OrderUID: "b", ChrtID: 3, Name: "third", Price: 30},
+			},
+		},
+	}
+}
+
+func TestRun_CSVOneRowPerItem(t *testing.T) {
+	var buf bytes.Buffer
+
+	count, err := Run(context.Background(), &fakeStreamer{orders: testOrders()}, repository.OrderFilter{}, FormatCSV, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 orders, got %d", count)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected header and 3 item rows, got %d rows", len(records))
+	}
+	if records[0][0] != "order_uid" {
+		t.Fatalf("unexpected header: %v", records[0])
+	}
+	if records[2][0] != "a" || records[3][0] != "b" {
+		t.Fatalf("unexpected order uids: %q, %q", records[2][0], records[3][0])
+	}
+}
+
+func TestRun_NDJSONOneLinePerOrder(t *testing.T) {
+	var buf bytes.Buffer
+
+	if _, err := Run(context.Background(), &fakeStreamer{orders: testOrders()}, repository.OrderFilter{}, FormatNDJSON, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	var uids []string
+	for scanner.Scan() {
+		var o models.Order
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			t.Fatalf("invalid json line: %v", err)
+		}
+		uids = append(uids, o.OrderUID)
+	}
+
+	if len(uids) != 2 || uids[0] != "a" || uids[1] != "b" {
+		t.Fatalf("unexpected orders: %v", uids)
+	}
+}
+
+func TestRun_ParquetFileLayout(t *testing.T) {
+	var buf bytes.Buffer
+
+	if _, err := Run(context.Background(), &fakeStreamer{orders: testOrders()}, repository.OrderFilter{}, FormatParquet, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, parquetMagic) || !bytes.HasSuffix(data, parquetMagic) {
+		t.Fatalf("missing parquet magic bytes")
+	}
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	if footerLen <= 0 || footerLen > len(data)-12 {
+		t.Fatalf("invalid footer length %d for file of %d bytes", footerLen, len(data))
+	}
+
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	for _, col := range flatColumns {
+		if !bytes.Contains(footer, []byte(col.name)) {
+			t.Fatalf("footer does not describe column %s", col.name)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(""); err != nil || f != FormatCSV {
+		t.Fatalf("expected csv by default, got %q, %v", f, err)
+	}
+	if f, err := ParseFormat("Parquet"); err != nil || f != FormatParquet {
+		t.Fatalf("expected parquet, got %q, %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+}
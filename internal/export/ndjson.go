@@ -0,0 +1,30 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{
+		buf: buf,
+		enc: json.NewEncoder(buf),
+	}
+}
+
+func (nw *ndjsonWriter) Write(order *models.Order) error {
+	return nw.enc.Encode(order)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.buf.Flush()
+}
@@ -0,0 +1,250 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+// The Parquet writer below produces the simplest valid layout: a flat schema
+// of REQUIRED columns, PLAIN encoding, no compression and a single data page
+// per column chunk. Rows are buffered only up to parquetRowGroupSize before
+// being flushed as a row group, which keeps memory bounded on large exports.
+
+const parquetRowGroupSize = 10000
+
+var parquetMagic = []byte("PAR1")
+
+// Parquet physical and converted types, see parquet.thrift.
+const (
+	parquetTypeInt32     = 1
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetRepetitionRequired = 0
+	parquetEncodingPlain      = 0
+	parquetEncodingRLE        = 3
+	parquetCodecUncompressed  = 0
+	parquetPageTypeData       = 0
+)
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+}
+
+type parquetRowGroup struct {
+	numRows int64
+	chunks  []parquetColumnChunk
+}
+
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	started   bool
+	columns   []bytes.Buffer
+	rows      int
+	totalRows int64
+	groups    []parquetRowGroup
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{
+		w:       w,
+		columns: make([]bytes.Buffer, len(flatColumns)),
+	}
+}
+
+func (pw *parquetWriter) Write(order *models.Order) error {
+	for _, row := range flatten(order) {
+		for i, v := range row {
+			appendPlain(&pw.columns[i], flatColumns[i].kind, v)
+		}
+		pw.rows++
+
+		if pw.rows >= parquetRowGroupSize {
+			if err := pw.flushRowGroup(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (pw *parquetWriter) Close() error {
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+	if !pw.started {
+		if err := pw.write(parquetMagic); err != nil {
+			return err
+		}
+	}
+
+	footer := pw.fileMetaData()
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+
+	var tail [8]byte
+	binary.LittleEndian.PutUint32(tail[:4], uint32(len(footer)))
+	copy(tail[4:], parquetMagic)
+	return pw.write(tail[:])
+}
+
+func (pw *parquetWriter) write(p []byte) error {
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *parquetWriter) flushRowGroup() error {
+	if pw.rows == 0 {
+		return nil
+	}
+
+	if !pw.started {
+		if err := pw.write(parquetMagic); err != nil {
+			return err
+		}
+		pw.started = true
+	}
+
+	group := parquetRowGroup{
+		numRows: int64(pw.rows),
+		chunks:  make([]parquetColumnChunk, len(pw.columns)),
+	}
+
+	for i := range pw.columns {
+		data := pw.columns[i].Bytes()
+		header := dataPageHeader(pw.rows, len(data))
+
+		group.chunks[i] = parquetColumnChunk{
+			offset: pw.offset,
+			size:   int64(len(header) + len(data)),
+		}
+
+		if err := pw.write(header); err != nil {
+			return err
+		}
+		if err := pw.write(data); err != nil {
+			return err
+		}
+		pw.columns[i].Reset()
+	}
+
+	pw.groups = append(pw.groups, group)
+	pw.totalRows += int64(pw.rows)
+	pw.rows = 0
+	return nil
+}
+
+func appendPlain(buf *bytes.Buffer, kind columnKind, v any) {
+	switch kind {
+	case kindString:
+		s := v.(string)
+		_ = binary.Write(buf, binary.LittleEndian, uint32(len(s)))
+		buf.WriteString(s)
+	case kindInt32:
+		_ = binary.Write(buf, binary.LittleEndian, v.(int32))
+	case kindInt64:
+		_ = binary.Write(buf, binary.LittleEndian, v.(int64))
+	case kindTime:
+		_ = binary.Write(buf, binary.LittleEndian, v.(time.Time).UnixMilli())
+	}
+}
+
+func parquetPhysicalType(kind columnKind) int32 {
+	switch kind {
+	case kindInt32:
+		return parquetTypeInt32
+	case kindInt64, kindTime:
+		return parquetTypeInt64
+	default:
+		return parquetTypeByteArray
+	}
+}
+
+func dataPageHeader(numValues, size int) []byte {
+	var t thriftWriter
+	t.i32(1, parquetPageTypeData)
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.beginStruct(5)
+	t.i32(1, int32(numValues))
+	t.i32(2, parquetEncodingPlain)
+	t.i32(3, parquetEncodingRLE)
+	t.i32(4, parquetEncodingRLE)
+	t.endStruct()
+	t.stop()
+	return t.buf.Bytes()
+}
+
+func (pw *parquetWriter) fileMetaData() []byte {
+	var t thriftWriter
+	t.i32(1, 1)
+
+	t.beginList(2, thriftStruct, len(flatColumns)+1)
+	t.beginElem()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(flatColumns)))
+	t.endStruct()
+	for _, col := range flatColumns {
+		t.beginElem()
+		t.i32(1, parquetPhysicalType(col.kind))
+		t.i32(3, parquetRepetitionRequired)
+		t.binary(4, col.name)
+		switch col.kind {
+		case kindString:
+			t.i32(6, parquetConvertedUTF8)
+		case kindTime:
+			t.i32(6, parquetConvertedTimestampMillis)
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, pw.totalRows)
+
+	t.beginList(4, thriftStruct, len(pw.groups))
+	for _, g := range pw.groups {
+		t.beginElem()
+
+		var total int64
+		t.beginList(1, thriftStruct, len(g.chunks))
+		for i, chunk := range g.chunks {
+			col := flatColumns[i]
+			total += chunk.size
+
+			t.beginElem()
+			t.i64(2, chunk.offset)
+			t.beginStruct(3)
+			t.i32(1, parquetPhysicalType(col.kind))
+			t.beginList(2, thriftI32, 2)
+			t.listI32(parquetEncodingPlain)
+			t.listI32(parquetEncodingRLE)
+			t.beginList(3, thriftBinary, 1)
+			t.listBinary(col.name)
+			t.i32(4, parquetCodecUncompressed)
+			t.i64(5, g.numRows)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+
+		t.i64(2, total)
+		t.i64(3, g.numRows)
+		t.endStruct()
+	}
+
+	t.binary(6, "wb-service export")
+	t.stop()
+	return t.buf.Bytes()
+}
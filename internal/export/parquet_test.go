@@ -0,0 +1,287 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+// thriftReader decodes the Thrift compact protocol into maps of field id to
+// value, independently of thriftWriter: integers become int64, binaries
+// strings, lists []any and structs map[int16]any.
+type thriftReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[min(r.pos, len(r.data)):])
+	if n <= 0 {
+		r.fail("invalid varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("offset %d: %s", r.pos, fmt.Sprintf(format, args...))
+	}
+	r.pos = len(r.data)
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var id int16
+	for r.err == nil {
+		b := r.byte()
+		if b == 0 {
+			break
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.varint())
+		}
+		fields[id] = r.readValue(b & 0x0f)
+	}
+	return fields
+}
+
+func (r *thriftReader) readValue(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		if n > len(r.data)-r.pos {
+			r.fail("binary of %d bytes overruns the data", n)
+			return ""
+		}
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		b := r.byte()
+		size := int(b >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		items := make([]any, 0, min(size, len(r.data)))
+		for i := 0; i < size && r.err == nil; i++ {
+			items = append(items, r.readValue(b&0x0f))
+		}
+		return items
+	case thriftStruct:
+		return r.readStruct()
+	default:
+		r.fail("unexpected type %d", typ)
+		return nil
+	}
+}
+
+// readParquet reads back a file written by parquetWriter the way a generic
+// reader would: through the footer, the column chunk offsets and the page
+// headers. It returns the rows in the order they were written.
+func readParquet(data []byte) ([][]any, error) {
+	if len(data) < 12 || !bytes.HasPrefix(data, parquetMagic) || !bytes.HasSuffix(data, parquetMagic) {
+		return nil, fmt.Errorf("missing parquet magic bytes")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen > len(data)-12 {
+		return nil, fmt.Errorf("footer length %d overruns the file", footerLen)
+	}
+
+	footer := thriftReader{data: data[len(data)-8-footerLen : len(data)-8]}
+	meta := footer.readStruct()
+	if footer.err != nil {
+		return nil, fmt.Errorf("footer: %w", footer.err)
+	}
+	if footer.pos != footerLen {
+		return nil, fmt.Errorf("footer is %d bytes, metadata %d", footerLen, footer.pos)
+	}
+
+	schema, _ := meta[2].([]any)
+	if len(schema) != len(flatColumns)+1 || schema[0].(map[int16]any)[5] != int64(len(flatColumns)) {
+		return nil, fmt.Errorf("unexpected schema %v", schema)
+	}
+	for i, col := range flatColumns {
+		elem := schema[i+1].(map[int16]any)
+		if elem[4] != col.name || elem[1] != int64(parquetPhysicalType(col.kind)) || elem[3] != int64(parquetRepetitionRequired) {
+			return nil, fmt.Errorf("schema element %d = %v, want column %s", i+1, elem, col.name)
+		}
+	}
+
+	var rows [][]any
+	groups, _ := meta[4].([]any)
+	for g, group := range groups {
+		group := group.(map[int16]any)
+		numRows := group[3].(int64)
+		chunks, _ := group[1].([]any)
+		if len(chunks) != len(flatColumns) {
+			return nil, fmt.Errorf("row group %d has %d column chunks", g, len(chunks))
+		}
+
+		groupRows := make([][]any, numRows)
+		for i := range groupRows {
+			groupRows[i] = make([]any, len(flatColumns))
+		}
+		for c, chunk := range chunks {
+			values, err := readColumnChunk(data, chunk.(map[int16]any), flatColumns[c], numRows)
+			if err != nil {
+				return nil, fmt.Errorf("row group %d, column %s: %w", g, flatColumns[c].name, err)
+			}
+			for i, v := range values {
+				groupRows[i][c] = v
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+
+	if meta[3] != int64(len(rows)) {
+		return nil, fmt.Errorf("footer counts %v rows, row groups hold %d", meta[3], len(rows))
+	}
+	return rows, nil
+}
+
+func readColumnChunk(data []byte, chunk map[int16]any, col column, numRows int64) ([]any, error) {
+	meta := chunk[3].(map[int16]any)
+	if meta[3].([]any)[0] != col.name || meta[4] != int64(parquetCodecUncompressed) || meta[5] != numRows {
+		return nil, fmt.Errorf("unexpected column metadata %v", meta)
+	}
+	offset, size := meta[9].(int64), meta[7].(int64)
+	if chunk[2] != offset {
+		return nil, fmt.Errorf("chunk at %v, its data page at %d", chunk[2], offset)
+	}
+	if offset < 4 || offset+size > int64(len(data)) {
+		return nil, fmt.Errorf("chunk of %d bytes at %d does not fit the file", size, offset)
+	}
+
+	page := thriftReader{data: data[offset : offset+size]}
+	header := page.readStruct()
+	if page.err != nil {
+		return nil, fmt.Errorf("page header: %w", page.err)
+	}
+	dataPage, _ := header[5].(map[int16]any)
+	if header[1] != int64(parquetPageTypeData) || dataPage[1] != numRows || dataPage[2] != int64(parquetEncodingPlain) {
+		return nil, fmt.Errorf("unexpected page header %v", header)
+	}
+	body := page.data[page.pos:]
+	if header[3] != int64(len(body)) {
+		return nil, fmt.Errorf("page header gives %v bytes, chunk holds %d", header[3], len(body))
+	}
+
+	values := make([]any, 0, numRows)
+	for range numRows {
+		var v any
+		switch col.kind {
+		case kindString:
+			if len(body) < 4 || int(binary.LittleEndian.Uint32(body)) > len(body)-4 {
+				return nil, fmt.Errorf("truncated string")
+			}
+			n := binary.LittleEndian.Uint32(body)
+			v, body = string(body[4:4+n]), body[4+n:]
+		case kindInt32:
+			if len(body) < 4 {
+				return nil, fmt.Errorf("truncated int32")
+			}
+			v, body = int32(binary.LittleEndian.Uint32(body)), body[4:]
+		case kindInt64, kindTime:
+			if len(body) < 8 {
+				return nil, fmt.Errorf("truncated int64")
+			}
+			n := int64(binary.LittleEndian.Uint64(body))
+			v, body = n, body[8:]
+			if col.kind == kindTime {
+				v = time.UnixMilli(n).UTC()
+			}
+		}
+		values = append(values, v)
+	}
+	if len(body) != 0 {
+		return nil, fmt.Errorf("%d bytes left after %d values", len(body), numRows)
+	}
+	return values, nil
+}
+
+func TestRun_ParquetReadsBack(t *testing.T) {
+	sig := "подпись"
+	orders := testOrders()
+	orders[0].InternalSignature = &sig
+	orders[0].Payment.Amount = math.MaxInt32
+	orders[1].Items[0].ChrtID = math.MinInt64
+
+	// Enough rows for a second row group.
+	big := &models.Order{OrderUID: "c", DateCreated: time.Date(2024, 3, 4, 5, 6, 7, 8e6, time.UTC)}
+	for i := range parquetRowGroupSize {
+		big.Items = append(big.Items, models.Item{OrderUID: "c", ChrtID: int64(i), Name: fmt.Sprint("item ", i)})
+	}
+	orders = append(orders, big, &models.Order{OrderUID: "d"})
+
+	var buf bytes.Buffer
+	if _, err := Run(context.Background(), &fakeStreamer{orders: orders}, repository.OrderFilter{}, FormatParquet, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+
+	var want [][]any
+	for _, o := range orders {
+		want = append(want, flatten(o)...)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		for c, col := range flatColumns {
+			if w, ok := want[i][c].(time.Time); ok {
+				if g, _ := got[i][c].(time.Time); !g.Equal(w.Truncate(time.Millisecond)) {
+					t.Fatalf("row %d, %s = %v, want %v", i, col.name, got[i][c], w)
+				}
+				continue
+			}
+			if got[i][c] != want[i][c] {
+				t.Fatalf("row %d, %s = %#v, want %#v", i, col.name, got[i][c], want[i][c])
+			}
+		}
+	}
+}
+
+func TestRun_ParquetEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Run(context.Background(), &fakeStreamer{}, repository.OrderFilter{}, FormatParquet, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := readParquet(buf.Bytes())
+	if err != nil || len(rows) != 0 {
+		t.Fatalf("expected an empty file, got %d rows, %v", len(rows), err)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/auth"
+	"github.com/sonni-a/wb-service/internal/export"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditPage is a page of audit log entries, newest first.
+type AuditPage struct {
+	Entries []models.AuditEntry `json:"entries"`
+	// NextBeforeID is passed as before_id to get the next page; it is
+	// omitted on the last page.
+	NextBeforeID *int64 `json:"next_before_id,omitempty"`
+}
+
+type AuditHandler struct {
+	repo repository.AuditRepo
+}
+
+func NewAuditHandler(repo repository.AuditRepo) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+// requestActor is who r acts on behalf of, for the audit log.
+func requestActor(r *http.Request) audit.Actor {
+	name := "anonymous"
+	if p, ok := auth.FromContext(r.Context()); ok {
+		name = p.Subject
+	}
+	return audit.Actor{Name: name, Source: audit.SourceHTTP}
+}
+
+// ListAudit godoc
+// @Summary      List audit log entries
+// @Description  Returns audit log entries matching the filters, newest first. Order changes carry a diff with the values before and after; delivery PII is never recorded in clear.
+// @Tags         admin
+// @Produce      json
+// @Param        actor      query     string  false  "API key name, token subject, consumer group or OS user"
+// @Param        source     query     string  false  "http, kafka, cli or signal"
+// @Param        action     query     string  false  "Action, e.g. order.create, customer.erase or config.reload"
+// @Param        order_uid  query     string  false  "Order UID"
+// @Param        from       query     string  false  "Recorded at or after (RFC3339 or YYYY-MM-DD)"
+// @Param        to         query     string  false  "Recorded before (RFC3339 or YYYY-MM-DD)"
+// @Param        before_id  query     int     false  "Only entries older than this ID, from next_before_id"
+// @Param        limit      query     int     false  "Page size, 1 to 1000 (default 100)"
+// @Success      200        {object}  AuditPage
+// @Failure      400        {string}  string  "bad request"
+// @Failure      401        {object}  auth.Error
+// @Failure      403        {object}  auth.Error
+// @Failure      429        {object}  auth.Error
+// @Failure      500        {string}  string  "internal error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /audit [get]
+func (h *AuditHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.repo.List(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list audit entries", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	page := AuditPage{Entries: entries}
+	if len(entries) == filter.Limit {
+		next := entries[len(entries)-1].ID
+		page.NextBeforeID = &next
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+func parseAuditFilter(query url.Values) (repository.AuditFilter, error) {
+	filter := repository.AuditFilter{
+		Actor:    query.Get("actor"),
+		Source:   query.Get("source"),
+		Action:   query.Get("action"),
+		OrderUID: query.Get("order_uid"),
+		Limit:    defaultAuditLimit,
+	}
+
+	if filter.OrderUID != "" {
+		if err := uuid.Validate(filter.OrderUID); err != nil {
+			return filter, fmt.Errorf("invalid order_uid %q", filter.OrderUID)
+		}
+	}
+
+	var err error
+	if filter.From, err = export.ParseTime(query.Get("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = export.ParseTime(query.Get("to")); err != nil {
+		return filter, err
+	}
+
+	if s := query.Get("before_id"); s != "" {
+		if filter.BeforeID, err = strconv.ParseInt(s, 10, 64); err != nil || filter.BeforeID <= 0 {
+			return filter, fmt.Errorf("invalid before_id %q", s)
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		if filter.Limit, err = strconv.Atoi(s); err != nil || filter.Limit < 1 || filter.Limit > maxAuditLimit {
+			return filter, fmt.Errorf("invalid limit %q: must be between 1 and %d", s, maxAuditLimit)
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+	"github.com/sonni-a/wb-service/internal/repository/mock_repository"
+)
+
+func TestAuditHandler_ListAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repository.NewMockAuditRepo(ctrl)
+	handler := NewAuditHandler(mockRepo)
+
+	uid := "550e8400-e29b-41d4-a716-446655440000"
+	want := repository.AuditFilter{
+		Actor:    "ci",
+		Source:   "http",
+		Action:   "order.create",
+		OrderUID: uid,
+		From:     time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		BeforeID: 42,
+		Limit:    2,
+	}
+	mockRepo.EXPECT().List(gomock.Any(), want).Return([]models.AuditEntry{
+		{ID: 41, Actor: "ci", Source: "http", Action: "order.create", OrderUID: &uid},
+		{ID: 40, Actor: "ci", Source: "http", Action: "order.create", OrderUID: &uid},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/audit?actor=ci&source=http&action=order.create&order_uid="+uid+"&from=2026-10-01&before_id=42&limit=2", nil)
+	w := httptest.NewRecorder()
+
+	handler.ListAudit(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body)
+	}
+	var page AuditPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 2 || page.NextBeforeID == nil || *page.NextBeforeID != 40 {
+		t.Errorf("unexpected page: %+v", page)
+	}
+}
+
+func TestAuditHandler_ListAudit_LastPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repository.NewMockAuditRepo(ctrl)
+	handler := NewAuditHandler(mockRepo)
+
+	mockRepo.EXPECT().List(gomock.Any(), repository.AuditFilter{Limit: defaultAuditLimit}).Return([]models.AuditEntry{}, nil)
+
+	w := httptest.NewRecorder()
+	handler.ListAudit(w, httptest.NewRequest(http.MethodGet, "/audit", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	if body := w.Body.String(); body != "{\"entries\":[]}\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestAuditHandler_ListAudit_BadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewAuditHandler(mock_repository.NewMockAuditRepo(ctrl))
+
+	for _, query := range []string{"limit=0", "limit=1001", "before_id=x", "order_uid=not-a-uuid", "from=yesterday"} {
+		w := httptest.NewRecorder()
+		handler.ListAudit(w, httptest.NewRequest(http.MethodGet, "/audit?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
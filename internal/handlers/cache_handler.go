@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/logging"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/service"
+)
+
+// Audit log actions of the cache admin endpoints.
+const (
+	ActionEvictCache = "cache.evict"
+	ActionClearCache = "cache.clear"
+	ActionWarmCache  = "cache.warm"
+)
+
+// WarmResult reports a cache warm-up.
+type WarmResult struct {
+	Loaded int `json:"loaded"`
+}
+
+type CacheHandler struct {
+	cache    service.CacheAdmin
+	recorder audit.Recorder
+}
+
+func NewCacheHandler(cache service.CacheAdmin, recorder audit.Recorder) *CacheHandler {
+	return &CacheHandler{cache: cache, recorder: recorder}
+}
+
+// GetCacheStats godoc
+// @Summary      Cache statistics
+// @Description  Returns the number of cached orders, the hit ratio since startup and the age of the oldest entry
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  service.CacheStats
+// @Failure      401  {object}  auth.Error
+// @Failure      403  {object}  auth.Error
+// @Failure      429  {object}  auth.Error
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /cache/stats [get]
+func (h *CacheHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.cache.CacheStats())
+}
+
+// EvictOrder godoc
+// @Summary      Evict an order from the cache
+// @Description  Drops the order from the cache; the next read loads it from PostgreSQL. Succeeds whether or not the order was cached.
+// @Tags         admin
+// @Param        uid  path  string  true  "Order UID"
+// @Success      204
+// @Failure      401  {object}  auth.Error
+// @Failure      403  {object}  auth.Error
+// @Failure      429  {object}  auth.Error
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /cache/orders/{uid} [delete]
+func (h *CacheHandler) EvictOrder(w http.ResponseWriter, r *http.Request) {
+	uid := r.PathValue("uid")
+	ctx := logging.With(audit.WithActor(r.Context(), requestActor(r)), logging.KeyOrderUID, uid)
+	h.cache.EvictOrder(uid)
+	slog.InfoContext(ctx, "Evicted order from cache")
+	h.record(ctx, models.AuditEntry{Action: ActionEvictCache, OrderUID: &uid})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ClearCache godoc
+// @Summary      Clear the cache
+// @Description  Drops every cached order; reads then go to PostgreSQL until the cache fills up again
+// @Tags         admin
+// @Success      204
+// @Failure      401  {object}  auth.Error
+// @Failure      403  {object}  auth.Error
+// @Failure      429  {object}  auth.Error
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /cache [delete]
+func (h *CacheHandler) ClearCache(w http.ResponseWriter, r *http.Request) {
+	ctx := audit.WithActor(r.Context(), requestActor(r))
+	h.cache.ClearCache()
+	slog.InfoContext(ctx, "Cleared cache")
+	h.record(ctx, models.AuditEntry{Action: ActionClearCache})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WarmCache godoc
+// @Summary      Warm the cache
+// @Description  Loads orders from PostgreSQL into the cache, as on startup, replacing cached copies. Only as many orders as fit in the cache stay in it.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  WarmResult
+// @Failure      401  {object}  auth.Error
+// @Failure      403  {object}  auth.Error
+// @Failure      429  {object}  auth.Error
+// @Failure      500  {string}  string  "internal error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /cache/warm [post]
+func (h *CacheHandler) WarmCache(w http.ResponseWriter, r *http.Request) {
+	ctx := audit.WithActor(r.Context(), requestActor(r))
+	n, err := h.cache.LoadCache(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to warm cache", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "Warmed cache", "loaded", n)
+	h.record(ctx, models.AuditEntry{
+		Action: ActionWarmCache,
+		Diff:   map[string]models.FieldChange{"loaded": {After: n}},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(WarmResult{Loaded: n})
+}
+
+// record appends entry to the audit log. The cache is changed already, so a
+// failure to record it does not fail the request.
+func (h *CacheHandler) record(ctx context.Context, entry models.AuditEntry) {
+	if err := h.recorder.Record(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "Failed to audit cache change", "action", entry.Action, "error", err)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/service"
+	"github.com/sonni-a/wb-service/internal/service/mock_service"
+)
+
+type fakeRecorder struct {
+	entries []models.AuditEntry
+	actors  []audit.Actor
+}
+
+func (f *fakeRecorder) Record(ctx context.Context, entry models.AuditEntry) error {
+	f.entries = append(f.entries, entry)
+	f.actors = append(f.actors, audit.ActorFrom(ctx))
+	return nil
+}
+
+// recorded fails t unless rec holds exactly one entry of action, recorded
+// on behalf of an HTTP request, and returns it.
+func recorded(t *testing.T, rec *fakeRecorder, action string) models.AuditEntry {
+	t.Helper()
+	if len(rec.entries) != 1 || rec.entries[0].Action != action {
+		t.Fatalf("audit entries = %+v, want one %s", rec.entries, action)
+	}
+	if rec.actors[0].Source != audit.SourceHTTP {
+		t.Errorf("audit actor = %+v", rec.actors[0])
+	}
+	return rec.entries[0]
+}
+
+func TestCacheHandler_GetCacheStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mock_service.NewMockCacheAdmin(ctrl)
+	rec := &fakeRecorder{}
+	handler := NewCacheHandler(mockCache, rec)
+
+	want := service.CacheStats{Size: 3, MaxSize: 100, Hits: 9, Misses: 1, HitRatio: 0.9, OldestEntryAgeSeconds: 42}
+	mockCache.EXPECT().CacheStats().Return(want)
+
+	w := httptest.NewRecorder()
+	handler.GetCacheStats(w, httptest.NewRequest(http.MethodGet, "/cache/stats", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	var got service.CacheStats
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got != want {
+		t.Errorf("unexpected body %s: %v", w.Body, err)
+	}
+	if len(rec.entries) != 0 {
+		t.Errorf("reading stats should not be audited, got %+v", rec.entries)
+	}
+}
+
+func TestCacheHandler_EvictOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mock_service.NewMockCacheAdmin(ctrl)
+	rec := &fakeRecorder{}
+	handler := NewCacheHandler(mockCache, rec)
+
+	mockCache.EXPECT().EvictOrder("b563feb7b2b84b6test")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /cache/orders/{uid}", handler.EvictOrder)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/cache/orders/b563feb7b2b84b6test", nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+	if e := recorded(t, rec, ActionEvictCache); e.OrderUID == nil || *e.OrderUID != "b563feb7b2b84b6test" {
+		t.Errorf("audit entry = %+v", e)
+	}
+}
+
+func TestCacheHandler_ClearCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mock_service.NewMockCacheAdmin(ctrl)
+	rec := &fakeRecorder{}
+	handler := NewCacheHandler(mockCache, rec)
+
+	mockCache.EXPECT().ClearCache()
+
+	w := httptest.NewRecorder()
+	handler.ClearCache(w, httptest.NewRequest(http.MethodDelete, "/cache", nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+	recorded(t, rec, ActionClearCache)
+}
+
+func TestCacheHandler_WarmCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mock_service.NewMockCacheAdmin(ctrl)
+	rec := &fakeRecorder{}
+	handler := NewCacheHandler(mockCache, rec)
+
+	mockCache.EXPECT().LoadCache(gomock.Any()).Return(12, nil)
+
+	w := httptest.NewRecorder()
+	handler.WarmCache(w, httptest.NewRequest(http.MethodPost, "/cache/warm", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	var res WarmResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res.Loaded != 12 {
+		t.Errorf("unexpected body %s: %v", w.Body, err)
+	}
+	if e := recorded(t, rec, ActionWarmCache); e.Diff["loaded"].After != 12 {
+		t.Errorf("audit entry = %+v", e)
+	}
+}
+
+func TestCacheHandler_WarmCache_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mock_service.NewMockCacheAdmin(ctrl)
+	rec := &fakeRecorder{}
+	handler := NewCacheHandler(mockCache, rec)
+
+	mockCache.EXPECT().LoadCache(gomock.Any()).Return(0, errors.New("db down"))
+
+	w := httptest.NewRecorder()
+	handler.WarmCache(w, httptest.NewRequest(http.MethodPost, "/cache/warm", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+	if len(rec.entries) != 0 {
+		t.Errorf("a failed warm-up should not be audited, got %+v", rec.entries)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/config"
+)
+
+type ConfigHandler struct {
+	reloader *config.Reloader
+}
+
+func NewConfigHandler(reloader *config.Reloader) *ConfigHandler {
+	return &ConfigHandler{reloader: reloader}
+}
+
+// GetConfig godoc
+// @Summary      Active configuration
+// @Description  Returns the active configuration as YAML with secrets redacted; the version is in the X-Config-Version header
+// @Tags         admin
+// @Produce      application/yaml
+// @Success      200  {string}  string
+// @Failure      401  {object}  auth.Error
+// @Failure      403  {object}  auth.Error
+// @Failure      429  {object}  auth.Error
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/config [get]
+func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("X-Config-Version", strconv.FormatUint(h.reloader.Version(), 10))
+	if err := h.reloader.Current().WriteYAML(w); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write config", "error", err)
+	}
+}
+
+// ReloadConfig godoc
+// @Summary      Reload configuration
+// @Description  Re-reads the config file and environment, like SIGHUP, and applies the settings that can change at runtime
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  config.ReloadResult
+// @Failure      401  {object}  auth.Error
+// @Failure      403  {object}  auth.Error
+// @Failure      429  {object}  auth.Error
+// @Failure      422  {string}  string  "invalid configuration"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/config/reload [post]
+func (h *ConfigHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	res, err := h.reloader.Reload(audit.WithActor(r.Context(), requestActor(r)))
+	if err != nil {
+		http.Error(w, "invalid configuration, keeping the active one: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
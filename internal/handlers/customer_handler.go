@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+// CustomerDataExport is the archive of everything stored about a customer.
+type CustomerDataExport struct {
+	CustomerID string         `json:"customer_id"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Orders     []models.Order `json:"orders"`
+}
+
+// EraseResult lists the orders whose delivery PII was erased.
+type EraseResult struct {
+	CustomerID string   `json:"customer_id"`
+	Erased     int      `json:"erased"`
+	OrderUIDs  []string `json:"order_uids"`
+}
+
+// ExportCustomerData godoc
+// @Summary      Export customer data
+// @Description  Returns every order of the customer, including delivery PII in clear, as a JSON archive.
+// @Description  Orders are streamed from PostgreSQL; a failure midway leaves the archive truncated.
+// @Tags         customers
+// @Produce      json
+// @Param        customer_id  path      string  true  "Customer ID"
+// @Success      200          {object}  CustomerDataExport
+// @Failure      401          {object}  auth.Error
+// @Failure      403          {object}  auth.Error
+// @Failure      429          {object}  auth.Error
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /customers/{customer_id}/data-export [get]
+func (h *OrderHandler) ExportCustomerData(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("customer_id")
+	if customerID == "" {
+		http.Error(w, "missing customer_id", http.StatusBadRequest)
+		return
+	}
+
+	head, _ := json.Marshal(struct {
+		CustomerID string    `json:"customer_id"`
+		ExportedAt time.Time `json:"exported_at"`
+	}{customerID, time.Now().UTC()})
+
+	// Archives are streamed for as long as the result set takes and are
+	// not bound by the server write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="customer-data.json"`)
+
+	// The archive is written as CustomerDataExport, one order at a time:
+	// the head object without its closing brace, then the orders array.
+	_, _ = w.Write(head[:len(head)-1])
+	_, _ = w.Write([]byte(`,"orders":[`))
+
+	count := 0
+	enc := json.NewEncoder(w)
+	err := h.service.StreamOrders(r.Context(), repository.OrderFilter{CustomerID: customerID}, func(order *models.Order) error {
+		if count > 0 {
+			if _, err := w.Write([]byte{','}); err != nil {
+				return err
+			}
+		}
+		count++
+		return enc.Encode(order)
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Customer data export failed", "exported", count, "error", err)
+		return
+	}
+	_, _ = w.Write([]byte("]}\n"))
+
+	slog.InfoContext(r.Context(), "Exported customer data", "orders", count)
+}
+
+// EraseCustomer godoc
+// @Summary      Erase customer PII
+// @Description  Replaces the delivery name, phone, email and address of every order of the customer with "[erased]".
+// @Description  Orders, payments and items are kept. Each erased order gets an audit log entry; orders erased before are skipped.
+// @Tags         customers
+// @Produce      json
+// @Param        customer_id  path      string  true  "Customer ID"
+// @Success      200          {object}  EraseResult
+// @Failure      401          {object}  auth.Error
+// @Failure      403          {object}  auth.Error
+// @Failure      429          {object}  auth.Error
+// @Failure      500          {string}  string  "internal error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /customers/{customer_id}/erase [post]
+func (h *OrderHandler) EraseCustomer(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("customer_id")
+	if customerID == "" {
+		http.Error(w, "missing customer_id", http.StatusBadRequest)
+		return
+	}
+
+	ctx := audit.WithActor(r.Context(), requestActor(r))
+	uids, err := h.service.EraseCustomer(ctx, customerID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to erase customer data", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "Erased customer data", "orders", len(uids))
+	if uids == nil {
+		uids = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(EraseResult{CustomerID: customerID, Erased: len(uids), OrderUIDs: uids})
+}
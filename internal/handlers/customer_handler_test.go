@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+	"github.com/sonni-a/wb-service/internal/service/mock_service"
+)
+
+func TestOrderHandler_ExportCustomerData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, false)
+
+	first, second := validTestOrder(), validTestOrder()
+	second.OrderUID = "660e8400-e29b-41d4-a716-446655440000"
+
+	mockSvc.EXPECT().
+		StreamOrders(gomock.Any(), repository.OrderFilter{CustomerID: "customer-1"}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ repository.OrderFilter, fn func(*models.Order) error) error {
+			if err := fn(&first); err != nil {
+				return err
+			}
+			return fn(&second)
+		})
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/customer-1/data-export", nil)
+	req.SetPathValue("customer_id", "customer-1")
+	w := httptest.NewRecorder()
+
+	handler.ExportCustomerData(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	var got CustomerDataExport
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid archive %q: %v", w.Body, err)
+	}
+	if got.CustomerID != "customer-1" || got.ExportedAt.IsZero() || len(got.Orders) != 2 {
+		t.Fatalf("unexpected archive: %+v", got)
+	}
+	if got.Orders[1].OrderUID != second.OrderUID || got.Orders[0].Delivery.Email != first.Delivery.Email {
+		t.Errorf("orders not exported in full: %+v", got.Orders)
+	}
+}
+
+func TestOrderHandler_ExportCustomerData_NoOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, false)
+
+	mockSvc.EXPECT().StreamOrders(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/nobody/data-export", nil)
+	req.SetPathValue("customer_id", "nobody")
+	w := httptest.NewRecorder()
+
+	handler.ExportCustomerData(w, req)
+
+	var got CustomerDataExport
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid archive %q: %v", w.Body, err)
+	}
+	if got.Orders == nil || len(got.Orders) != 0 {
+		t.Errorf("orders = %v, want an empty list", got.Orders)
+	}
+}
+
+func TestOrderHandler_EraseCustomer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, false)
+
+	mockSvc.EXPECT().
+		EraseCustomer(gomock.Any(), "customer-1").
+		DoAndReturn(func(ctx context.Context, _ string) ([]string, error) {
+			if a := audit.ActorFrom(ctx); a != (audit.Actor{Name: "anonymous", Source: audit.SourceHTTP}) {
+				t.Errorf("actor = %+v", a)
+			}
+			return []string{"a", "b"}, nil
+		})
+
+	req := httptest.NewRequest(http.MethodPost, "/customers/customer-1/erase", nil)
+	req.SetPathValue("customer_id", "customer-1")
+	w := httptest.NewRecorder()
+
+	handler.EraseCustomer(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	var got EraseResult
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.CustomerID != "customer-1" || got.Erased != 2 || len(got.OrderUIDs) != 2 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
+
+func TestOrderHandler_EraseCustomer_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, false)
+
+	mockSvc.EXPECT().EraseCustomer(gomock.Any(), "customer-1").Return(nil, errors.New("db down"))
+
+	req := httptest.NewRequest(http.MethodPost, "/customers/customer-1/erase", nil)
+	req.SetPathValue("customer_id", "customer-1")
+	w := httptest.NewRecorder()
+
+	handler.EraseCustomer(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
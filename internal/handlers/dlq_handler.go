@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/kafka"
+)
+
+const (
+	defaultDLQLimit = 100
+	maxDLQLimit     = 1000
+)
+
+// DeadLetters is the DLQ the admin endpoints operate on, see kafka.DLQ.
+type DeadLetters interface {
+	Peek(ctx context.Context, limit int) ([]kafka.DLQMessage, error)
+	Replay(ctx context.Context, limit int) (kafka.ReplayResult, error)
+}
+
+type DLQHandler struct {
+	dlq DeadLetters
+}
+
+func NewDLQHandler(dlq DeadLetters) *DLQHandler {
+	return &DLQHandler{dlq: dlq}
+}
+
+// ListDLQ godoc
+// @Summary      List DLQ messages
+// @Description  Returns the messages waiting in the DLQ, oldest first per partition, with the reason they were rejected. Previews of the original messages have delivery PII redacted.
+// @Tags         admin
+// @Produce      json
+// @Param        limit  query     int  false  "Messages to return, 1 to 1000 (default 100)"
+// @Success      200    {array}   kafka.DLQMessage
+// @Failure      400    {string}  string  "bad request"
+// @Failure      401    {object}  auth.Error
+// @Failure      403    {object}  auth.Error
+// @Failure      429    {object}  auth.Error
+// @Failure      502    {string}  string  "Kafka unavailable"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /dlq/messages [get]
+func (h *DLQHandler) ListDLQ(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseDLQLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msgs, err := h.dlq.Peek(r.Context(), limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read DLQ", "error", err)
+		http.Error(w, "failed to read DLQ", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(msgs)
+}
+
+// ReplayDLQ godoc
+// @Summary      Replay DLQ messages
+// @Description  Sends up to limit DLQ messages back to the orders topic with their original key, value and content type; the consumer then processes them again. Orders already stored are skipped as duplicates.
+// @Tags         admin
+// @Produce      json
+// @Param        limit  query     int  false  "Messages to replay, 1 to 1000 (default 100)"
+// @Success      200    {object}  kafka.ReplayResult
+// @Failure      400    {string}  string  "bad request"
+// @Failure      401    {object}  auth.Error
+// @Failure      403    {object}  auth.Error
+// @Failure      429    {object}  auth.Error
+// @Failure      502    {object}  kafka.ReplayResult
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /dlq/replay [post]
+func (h *DLQHandler) ReplayDLQ(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseDLQLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := audit.WithActor(r.Context(), requestActor(r))
+	res, err := h.dlq.Replay(ctx, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		slog.ErrorContext(ctx, "DLQ replay failed", "error", err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func parseDLQLimit(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("limit")
+	if s == "" {
+		return defaultDLQLimit, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > maxDLQLimit {
+		return 0, fmt.Errorf("invalid limit %q: must be between 1 and %d", s, maxDLQLimit)
+	}
+	return limit, nil
+}
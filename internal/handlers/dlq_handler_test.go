@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/kafka"
+)
+
+type fakeDLQ struct {
+	limit int
+	actor audit.Actor
+	msgs  []kafka.DLQMessage
+	res   kafka.ReplayResult
+	err   error
+}
+
+func (f *fakeDLQ) Peek(_ context.Context, limit int) ([]kafka.DLQMessage, error) {
+	f.limit = limit
+	return f.msgs, f.err
+}
+
+func (f *fakeDLQ) Replay(ctx context.Context, limit int) (kafka.ReplayResult, error) {
+	f.limit, f.actor = limit, audit.ActorFrom(ctx)
+	return f.res, f.err
+}
+
+func TestDLQHandler_ListDLQ(t *testing.T) {
+	dlq := &fakeDLQ{msgs: []kafka.DLQMessage{{Partition: 0, Offset: 7, Reason: "validation failed"}}}
+	handler := NewDLQHandler(dlq)
+
+	w := httptest.NewRecorder()
+	handler.ListDLQ(w, httptest.NewRequest(http.MethodGet, "/dlq/messages", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body)
+	}
+	if dlq.limit != defaultDLQLimit {
+		t.Errorf("limit = %d, want %d", dlq.limit, defaultDLQLimit)
+	}
+	var msgs []kafka.DLQMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &msgs); err != nil || len(msgs) != 1 || msgs[0].Offset != 7 {
+		t.Errorf("unexpected body %s: %v", w.Body, err)
+	}
+}
+
+func TestDLQHandler_InvalidLimit(t *testing.T) {
+	handler := NewDLQHandler(&fakeDLQ{})
+
+	for _, limit := range []string{"0", "1001", "x"} {
+		w := httptest.NewRecorder()
+		handler.ReplayDLQ(w, httptest.NewRequest(http.MethodPost, "/dlq/replay?limit="+limit, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s: expected 400, got %d", limit, w.Code)
+		}
+	}
+}
+
+func TestDLQHandler_ReplayDLQ(t *testing.T) {
+	dlq := &fakeDLQ{res: kafka.ReplayResult{Replayed: 3, Skipped: 1}}
+	handler := NewDLQHandler(dlq)
+
+	w := httptest.NewRecorder()
+	handler.ReplayDLQ(w, httptest.NewRequest(http.MethodPost, "/dlq/replay?limit=5", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", w.Code, w.Body)
+	}
+	if dlq.limit != 5 || dlq.actor.Source != audit.SourceHTTP {
+		t.Errorf("Replay called with limit %d and actor %+v", dlq.limit, dlq.actor)
+	}
+	var res kafka.ReplayResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res != dlq.res {
+		t.Errorf("unexpected body %s: %v", w.Body, err)
+	}
+}
+
+func TestDLQHandler_ReplayDLQ_PartialFailure(t *testing.T) {
+	dlq := &fakeDLQ{res: kafka.ReplayResult{Replayed: 2}, err: errors.New("commit failed")}
+	handler := NewDLQHandler(dlq)
+
+	w := httptest.NewRecorder()
+	handler.ReplayDLQ(w, httptest.NewRequest(http.MethodPost, "/dlq/replay", nil))
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", w.Code)
+	}
+	var res kafka.ReplayResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res.Replayed != 2 {
+		t.Errorf("the partial result should be reported, got %s", w.Body)
+	}
+}
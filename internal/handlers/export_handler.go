@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/export"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/pii"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+// ExportOrders godoc
+// @Summary      Export orders
+// @Description  Streams orders matching the filters as CSV, NDJSON or Parquet.
+// @Description  CSV and Parquet contain one row per item.
+// @Description  Delivery PII is masked as in GET /order/{uid}.
+// @Tags         orders
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.apache.parquet
+// @Param        format            query     string  false  "csv (default), ndjson or parquet"
+// @Param        customer_id       query     string  false  "Customer ID"
+// @Param        delivery_service  query     string  false  "Delivery service"
+// @Param        from              query     string  false  "Created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param        to                query     string  false  "Created before (RFC3339 or YYYY-MM-DD)"
+// @Success      200  {file}    file
+// @Failure      400  {string}  string  "bad request"
+// @Failure      401  {object}  auth.Error
+// @Failure      403  {object}  auth.Error
+// @Failure      429  {object}  auth.Error
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/export [get]
+func (h *OrderHandler) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format, err := export.ParseFormat(query.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseOrderFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Exports stream for as long as the result set takes and are not
+	// bound by the server write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="orders`+format.Extension()+`"`)
+
+	// Headers are already sent once streaming starts, so a failure past this
+	// point can only be logged and surfaces to the client as a truncated body.
+	var src export.OrderStreamer = h.service
+	if h.masked(r) {
+		src = maskedOrders{src}
+	}
+	count, err := export.Run(r.Context(), src, filter, format, w)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Order export failed", "exported", count, "error", err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Exported orders", "count", count, "format", format)
+}
+
+// maskedOrders masks the delivery PII of the orders it streams.
+type maskedOrders struct {
+	export.OrderStreamer
+}
+
+func (m maskedOrders) StreamOrders(ctx context.Context, filter repository.OrderFilter, fn func(*models.Order) error) error {
+	return m.OrderStreamer.StreamOrders(ctx, filter, func(order *models.Order) error {
+		return fn(pii.MaskOrder(order))
+	})
+}
+
+func parseOrderFilter(query url.Values) (repository.OrderFilter, error) {
+	from, err := export.ParseTime(query.Get("from"))
+	if err != nil {
+		return repository.OrderFilter{}, err
+	}
+
+	to, err := export.ParseTime(query.Get("to"))
+	if err != nil {
+		return repository.OrderFilter{}, err
+	}
+
+	return repository.OrderFilter{
+		CustomerID:      query.Get("customer_id"),
+		DeliveryService: query.Get("delivery_service"),
+		From:            from,
+		To:              to,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/importer"
+)
+
+// ImportOrders godoc
+// @Summary      Import orders
+// @Description  Streams an NDJSON or CSV file of orders, validates every record
+// @Description  and stores valid ones in batches. Returns a report of accepted,
+// @Description  duplicate and rejected records. Records stored before the body
+// @Description  exceeds the size limit are kept; the report then comes with 413.
+// @Tags         orders
+// @Accept       application/x-ndjson
+// @Accept       text/csv
+// @Produce      json
+// @Param        format      query     string  false  "ndjson (default) or csv"
+// @Param        batch_size  query     int     false  "Orders per insert transaction"
+// @Success      200         {object}  importer.Report
+// @Failure      400         {string}  string  "bad request"
+// @Failure      401         {object}  auth.Error
+// @Failure      403         {object}  auth.Error
+// @Failure      413         {object}  importer.Report
+// @Failure      429         {object}  auth.Error
+// @Failure      500         {object}  importer.Report
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /orders/import [post]
+func (h *OrderHandler) ImportOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format, err := importer.ParseFormat(query.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	batchSize := importer.DefaultBatchSize
+	if v := query.Get("batch_size"); v != "" {
+		batchSize, err = strconv.Atoi(v)
+		if err != nil || batchSize <= 0 {
+			http.Error(w, "invalid batch_size", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Imports may take longer than the server read and write timeouts
+	// allow; their size is bounded by maxImportBytes instead.
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+	body := http.MaxBytesReader(w, r.Body, h.maxImportBytes.Load())
+
+	ctx := audit.WithActor(r.Context(), requestActor(r))
+	report, err := importer.Run(ctx, h.service, format, body, batchSize)
+	if err != nil && report == nil {
+		if tooLarge(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		slog.WarnContext(r.Context(), "Import body exceeds the size limit", "limit", maxErr.Limit)
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Order import failed", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	slog.InfoContext(r.Context(), "Imported orders",
+		"accepted", report.Accepted, "duplicates", report.Duplicates, "rejected", report.Rejected)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/sonni-a/wb-service/internal/importer"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/service/mock_service"
+)
+
+func TestOrderHandler_ImportOrders_BodyTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, false)
+
+	line, _ := json.Marshal(validTestOrder())
+	line = append(line, '\n')
+	handler.SetBodyLimits(DefaultMaxBodyBytes, int64(len(line)+10))
+
+	// The first order is stored before the second one crosses the limit.
+	mockSvc.EXPECT().InsertOrders(gomock.Any(), gomock.Len(1)).Return([]error{nil}, nil)
+
+	body := bytes.Repeat(line, 2)
+	req := httptest.NewRequest(http.MethodPost, "/orders/import?batch_size=1", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ImportOrders(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", w.Code, w.Body)
+	}
+	var report importer.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Accepted != 1 {
+		t.Errorf("report = %+v, want the stored order accepted", report)
+	}
+}
+
+func TestOrderHandler_ImportOrders_OutlastsServerTimeouts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, false)
+
+	const timeout = 100 * time.Millisecond
+	mockSvc.EXPECT().InsertOrders(gomock.Any(), gomock.Len(1)).DoAndReturn(
+		func(context.Context, []*models.Order) ([]error, error) {
+			time.Sleep(3 * timeout)
+			return []error{nil}, nil
+		})
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(handler.ImportOrders))
+	srv.Config.ReadTimeout = timeout
+	srv.Config.WriteTimeout = timeout
+	srv.Start()
+	defer srv.Close()
+
+	line, _ := json.Marshal(validTestOrder())
+	resp, err := http.Post(srv.URL+"/orders/import", "application/x-ndjson", bytes.NewReader(line))
+	if err != nil {
+		t.Fatalf("the report was lost: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var report importer.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("the report was lost: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || report.Accepted != 1 {
+		t.Errorf("got %d %+v", resp.StatusCode, report)
+	}
+}
@@ -3,21 +3,78 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/auth"
+	"github.com/sonni-a/wb-service/internal/logging"
 	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/pii"
 	"github.com/sonni-a/wb-service/internal/service"
+	"github.com/sonni-a/wb-service/internal/strictjson"
 	"github.com/sonni-a/wb-service/internal/validator"
 )
 
+// Default request body limits, see SetBodyLimits.
+const (
+	DefaultMaxBodyBytes   = 1 << 20
+	DefaultMaxImportBytes = 100 << 20
+)
+
 type OrderHandler struct {
-	service service.OrderServiceInterface
+	service    service.OrderServiceInterface
+	strictJSON atomic.Bool
+
+	maxBodyBytes   atomic.Int64
+	maxImportBytes atomic.Int64
+
+	// unmaskRole is the role that sees delivery PII; RoleNone disables
+	// masking.
+	unmaskRole auth.Role
 }
 
-func NewOrderHandler(svc service.OrderServiceInterface) *OrderHandler {
-	return &OrderHandler{service: svc}
+// NewOrderHandler creates the order API handler. With strictJSON set,
+// request bodies with unknown fields or mistyped values are rejected and
+// the error names the offending JSON path.
+func NewOrderHandler(svc service.OrderServiceInterface, strictJSON bool) *OrderHandler {
+	h := &OrderHandler{service: svc}
+	h.strictJSON.Store(strictJSON)
+	h.SetBodyLimits(DefaultMaxBodyBytes, DefaultMaxImportBytes)
+	return h
+}
+
+// SetBodyLimits sets the largest request body accepted by CreateOrder and
+// by ImportOrders for requests that arrive from now on. Larger bodies are
+// rejected with 413.
+func (h *OrderHandler) SetBodyLimits(order, imports int64) {
+	h.maxBodyBytes.Store(order)
+	h.maxImportBytes.Store(imports)
+}
+
+// SetStrictJSON switches strict decoding for requests that arrive from now on.
+func (h *OrderHandler) SetStrictJSON(strict bool) {
+	h.strictJSON.Store(strict)
+}
+
+// MaskPII masks the delivery name, phone, email and address in orders
+// returned to callers below unmaskRole, and to every caller when
+// authentication is disabled. It must be called before serving requests.
+func (h *OrderHandler) MaskPII(unmaskRole auth.Role) {
+	h.unmaskRole = unmaskRole
+}
+
+// masked reports whether orders returned for r have their PII masked.
+func (h *OrderHandler) masked(r *http.Request) bool {
+	if h.unmaskRole == auth.RoleNone {
+		return false
+	}
+	p, ok := auth.FromContext(r.Context())
+	return !ok || p.Role < h.unmaskRole
 }
 
 // CreateOrder godoc
@@ -29,13 +86,23 @@ func NewOrderHandler(svc service.OrderServiceInterface) *OrderHandler {
 // @Param        order  body      models.Order  true  "Order data"
 // @Success      201    {object}  map[string]string
 // @Failure      400    {string}  string  "bad request"
+// @Failure      401    {object}  auth.Error
+// @Failure      403    {object}  auth.Error
 // @Failure      409    {string}  string  "order already exists"
+// @Failure      413    {string}  string  "request body too large"
+// @Failure      429    {object}  auth.Error
 // @Failure      500    {string}  string  "internal error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /order [post]
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var order models.Order
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	body := http.MaxBytesReader(w, r.Body, h.maxBodyBytes.Load())
+	if err := h.decodeOrder(body, &order); err != nil {
+		if tooLarge(w, err) {
+			return
+		}
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -44,7 +111,9 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.CreateOrder(r.Context(), &order)
+	ctx := logging.With(r.Context(), logging.KeyOrderUID, order.OrderUID)
+	ctx = audit.WithActor(ctx, requestActor(r))
+	err := h.service.CreateOrder(ctx, &order)
 	if err != nil {
 		if errors.Is(err, service.ErrOrderAlreadyExists) {
 			http.Error(w, "order already exists", http.StatusConflict)
@@ -53,12 +122,12 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
-			log.Printf("database error on create order: %v", err)
+			slog.ErrorContext(ctx, "Database error on create order", "error", err)
 			http.Error(w, "internal server error", http.StatusInternalServerError)
 			return
 		}
 
-		log.Printf("failed to create order: %v", err)
+		slog.ErrorContext(ctx, "Failed to create order", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -69,14 +138,20 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 // GetOrderByUID godoc
 // @Summary      Get order by UID
-// @Description  Returns order from cache or PostgreSQL
+// @Description  Returns order from cache or PostgreSQL.
+// @Description  Delivery name, phone, email and address are masked unless the caller's role may see them.
 // @Tags         orders
 // @Produce      json
 // @Param        uid  path      string  true  "Order UID"
 // @Success      200  {object}  models.Order
 // @Failure      400  {string}  string  "missing or invalid order_uid"
+// @Failure      401  {object}  auth.Error
+// @Failure      403  {object}  auth.Error
+// @Failure      429  {object}  auth.Error
 // @Failure      404  {string}  string  "order not found"
 // @Failure      500  {string}  string  "internal error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /order/{uid} [get]
 func (h *OrderHandler) GetOrderByUID(w http.ResponseWriter, r *http.Request) {
 	orderUID := r.PathValue("uid")
@@ -85,18 +160,46 @@ func (h *OrderHandler) GetOrderByUID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.service.GetOrder(r.Context(), orderUID)
+	ctx := logging.With(r.Context(), logging.KeyOrderUID, orderUID)
+	order, err := h.service.GetOrder(ctx, orderUID)
 	if err != nil {
 		if errors.Is(err, service.ErrOrderNotFound) {
 			http.Error(w, "order not found", http.StatusNotFound)
 			return
 		}
 
-		log.Printf("failed to get order %s: %v", orderUID, err)
+		slog.ErrorContext(ctx, "Failed to get order", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	if h.masked(r) {
+		order = pii.MaskOrder(order)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(order)
 }
+
+// tooLarge responds with 413 if err comes from reading a body over the
+// limit set with http.MaxBytesReader.
+func tooLarge(w http.ResponseWriter, err error) bool {
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		return false
+	}
+	http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+	return true
+}
+
+func (h *OrderHandler) decodeOrder(body io.Reader, order *models.Order) error {
+	if !h.strictJSON.Load() {
+		return json.NewDecoder(body).Decode(order)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return strictjson.Unmarshal(data, order)
+}
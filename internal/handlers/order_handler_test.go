@@ -2,15 +2,21 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 
+	"github.com/sonni-a/wb-service/internal/auth"
 	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
 	"github.com/sonni-a/wb-service/internal/service"
 	"github.com/sonni-a/wb-service/internal/service/mock_service"
 )
@@ -78,7 +84,7 @@ func TestOrderHandler_CreateOrder_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
-	handler := NewOrderHandler(mockSvc)
+	handler := NewOrderHandler(mockSvc, false)
 
 	order := validTestOrder()
 	body, _ := json.Marshal(order)
@@ -103,7 +109,7 @@ func TestOrderHandler_CreateOrder_InvalidOrder(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
-	handler := NewOrderHandler(mockSvc)
+	handler := NewOrderHandler(mockSvc, false)
 
 	order := models.Order{OrderUID: "123"}
 	body, _ := json.Marshal(order)
@@ -123,7 +129,7 @@ func TestOrderHandler_CreateOrder_InvalidJSON(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
-	handler := NewOrderHandler(mockSvc)
+	handler := NewOrderHandler(mockSvc, false)
 
 	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader([]byte("{bad json")))
 	w := httptest.NewRecorder()
@@ -135,12 +141,125 @@ func TestOrderHandler_CreateOrder_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestOrderHandler_CreateOrder_BodyTooLarge(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler := NewOrderHandler(mock_service.NewMockOrderServiceInterface(ctrl), strict)
+			handler.SetBodyLimits(64, DefaultMaxImportBytes)
+
+			body, _ := json.Marshal(validTestOrder())
+			req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+
+			handler.CreateOrder(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("expected 413, got %d: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_StrictUnknownField(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, true)
+
+	order := validTestOrder()
+	body, _ := json.Marshal(order)
+	body = bytes.Replace(body, []byte(`"delivery_service"`), []byte(`"delivery_servise"`), 1)
+
+	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.CreateOrder(w, req)
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown field, got %d", w.Result().StatusCode)
+	}
+	if !strings.Contains(w.Body.String(), "$.delivery_servise") {
+		t.Fatalf("expected field path in response, got %q", w.Body.String())
+	}
+}
+
+// A null internal_signature/request_id must survive POST and GET as null,
+// and an empty one as "", in both lenient and strict mode.
+func TestOrderHandler_NullableFieldsRoundTrip(t *testing.T) {
+	empty := ""
+
+	for _, strict := range []bool{false, true} {
+		for _, value := range []*string{nil, &empty} {
+			t.Run(fmt.Sprintf("strict=%t/nil=%t", strict, value == nil), func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+				handler := NewOrderHandler(mockSvc, strict)
+
+				order := validTestOrder()
+				order.InternalSignature = value
+				order.Payment.RequestID = value
+				body, _ := json.Marshal(order)
+
+				var stored *models.Order
+				mockSvc.EXPECT().
+					CreateOrder(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, o *models.Order) error {
+						stored = o
+						return nil
+					})
+
+				w := httptest.NewRecorder()
+				handler.CreateOrder(w, httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body)))
+				if w.Code != http.StatusCreated {
+					t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+				}
+				if !samePtr(stored.InternalSignature, value) || !samePtr(stored.Payment.RequestID, value) {
+					t.Fatalf("nullable fields changed on create: %v, %v", stored.InternalSignature, stored.Payment.RequestID)
+				}
+
+				mockSvc.EXPECT().GetOrder(gomock.Any(), order.OrderUID).Return(stored, nil)
+
+				req := httptest.NewRequest(http.MethodGet, "/order/"+order.OrderUID, nil)
+				req.SetPathValue("uid", order.OrderUID)
+				w = httptest.NewRecorder()
+				handler.GetOrderByUID(w, req)
+
+				var raw struct {
+					InternalSignature *string `json:"internal_signature"`
+					Payment           struct {
+						RequestID *string `json:"request_id"`
+					} `json:"payment"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+					t.Fatalf("decode response: %v", err)
+				}
+				if !samePtr(raw.InternalSignature, value) || !samePtr(raw.Payment.RequestID, value) {
+					t.Fatalf("nullable fields changed on get: %s", w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func samePtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func TestOrderHandler_GetOrderByUID_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
-	handler := NewOrderHandler(mockSvc)
+	handler := NewOrderHandler(mockSvc, false)
 
 	order := &models.Order{OrderUID: "abc"}
 
@@ -167,12 +286,80 @@ func TestOrderHandler_GetOrderByUID_Success(t *testing.T) {
 	}
 }
 
+func TestOrderHandler_GetOrderByUID_MasksPII(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, false)
+	handler.MaskPII(auth.RoleAdmin)
+
+	order := validTestOrder()
+	mockSvc.EXPECT().
+		GetOrder(gomock.Any(), order.OrderUID).
+		Return(&order, nil).
+		Times(3)
+
+	var keys []auth.APIKey
+	for _, k := range []string{"reader", "admin"} {
+		sum := sha256.Sum256([]byte(k + "-secret"))
+		key, err := auth.ParseAPIKey(k + ":" + k + ":" + hex.EncodeToString(sum[:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	protected := auth.New(keys, nil).Require(auth.RoleReader, http.HandlerFunc(handler.GetOrderByUID))
+
+	get := func(h http.Handler, apiKey string) models.Delivery {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/order/"+order.OrderUID, nil)
+		req.SetPathValue("uid", order.OrderUID)
+		if apiKey != "" {
+			req.Header.Set(auth.APIKeyHeader, apiKey)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 OK, got %d", w.Code)
+		}
+		var got models.Order
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Delivery
+	}
+
+	masked := models.Delivery{
+		OrderUID: order.OrderUID,
+		Name:     "J*** D***",
+		Phone:    "+1*****8901",
+		Zip:      "12345",
+		City:     "Moscow",
+		Address:  "R*** S*** 1***",
+		Region:   "Moscow",
+		Email:    "j***@example.com",
+	}
+	if got := get(http.HandlerFunc(handler.GetOrderByUID), ""); got != masked {
+		t.Errorf("anonymous caller got %+v, want %+v", got, masked)
+	}
+	if got := get(protected, "reader-secret"); got != masked {
+		t.Errorf("reader got %+v, want %+v", got, masked)
+	}
+	if got := get(protected, "admin-secret"); got != order.Delivery {
+		t.Errorf("admin got %+v, want %+v", got, order.Delivery)
+	}
+	if order.Delivery.Name != "John Doe" {
+		t.Errorf("masking modified the cached order: %+v", order.Delivery)
+	}
+}
+
 func TestOrderHandler_GetOrderByUID_NotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
-	handler := NewOrderHandler(mockSvc)
+	handler := NewOrderHandler(mockSvc, false)
 
 	mockSvc.EXPECT().
 		GetOrder(gomock.Any(), "zzz").
@@ -193,7 +380,7 @@ func TestOrderHandler_GetOrderByUID_BadURL(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	handler := NewOrderHandler(nil) // svc не нужен, ошибка до вызова
+	handler := NewOrderHandler(nil, false) // svc не нужен, ошибка до вызова
 
 	req := httptest.NewRequest(http.MethodGet, "/order", nil)
 	w := httptest.NewRecorder()
@@ -204,3 +391,72 @@ func TestOrderHandler_GetOrderByUID_BadURL(t *testing.T) {
 		t.Fatalf("expected 400")
 	}
 }
+
+func TestOrderHandler_ExportOrders_CSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSvc := mock_service.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockSvc, false)
+
+	order := validTestOrder()
+
+	mockSvc.EXPECT().
+		StreamOrders(gomock.Any(), repository.OrderFilter{CustomerID: "customer-1"}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ repository.OrderFilter, fn func(*models.Order) error) error {
+			return fn(&order)
+		})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/export?format=csv&customer_id=customer-1", nil)
+	w := httptest.NewRecorder()
+
+	handler.ExportOrders(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), order.OrderUID) {
+		t.Fatalf("expected exported order in body")
+	}
+}
+
+func TestOrderHandler_ExportOrders_BadFormat(t *testing.T) {
+	handler := NewOrderHandler(nil, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/export?format=xml", nil)
+	w := httptest.NewRecorder()
+
+	handler.ExportOrders(w, req)
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported format")
+	}
+}
+
+func TestOrderHandler_GetOrderSchema(t *testing.T) {
+	handler := NewOrderHandler(nil, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/schema/order.json", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetOrderSchema(w, req)
+
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Result().StatusCode)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/schema+json" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("invalid schema JSON: %v", err)
+	}
+	if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+		t.Fatalf("unexpected $schema: %v", schema["$schema"])
+	}
+}
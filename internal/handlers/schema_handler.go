@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/sonni-a/wb-service/internal/validator"
+)
+
+// GetOrderSchema godoc
+// @Summary      Order JSON Schema
+// @Description  Returns the JSON Schema (draft 2020-12) of the order payload accepted by POST /order and the Kafka consumer
+// @Tags         schema
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /schema/order.json [get]
+func (h *OrderHandler) GetOrderSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	_, _ = w.Write(validator.OrderSchemaJSON())
+}
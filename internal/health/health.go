@@ -0,0 +1,156 @@
+// Package health runs the checks behind the liveness and readiness probes.
+// Packages register checks of the components they own with a Registry; its
+// handler runs them all and reports each one's status and latency.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/metrics"
+)
+
+// Statuses of a component and of a whole report.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check reports whether a component works; a nil error means it does. It
+// must return once ctx is done.
+type Check func(ctx context.Context) error
+
+// Component is the result of one check.
+type Component struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the result of all checks of a registry. It is up only if every
+// component is.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Registry holds the checks of one probe.
+type Registry struct {
+	probe   string
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// NewRegistry creates the registry of probe, e.g. readiness. Each check
+// is given timeout to complete.
+func NewRegistry(probe string, timeout time.Duration) *Registry {
+	return &Registry{probe: probe, timeout: timeout}
+}
+
+// Register adds a check named after the component it checks. It panics if
+// the name is taken, as http.ServeMux does for patterns.
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.checks {
+		if c.name == name {
+			panic(fmt.Sprintf("health: check %q registered twice for %s", name, r.probe))
+		}
+	}
+	r.checks = append(r.checks, namedCheck{name: name, check: check})
+}
+
+// Run runs all checks in parallel and waits for them. A check that does
+// not finish within the timeout is down.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.checks
+	r.mu.RUnlock()
+
+	results := make([]Component, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(checks))}
+	for i, c := range checks {
+		report.Components[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c namedCheck) Component {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("no result within %s", r.timeout)
+	}
+
+	res := Component{Status: StatusUp, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	up := 1.0
+	if err != nil {
+		res.Status, res.Error = StatusDown, err.Error()
+		up = 0
+	}
+	metrics.HealthCheckUp.WithLabelValues(r.probe, c.name).Set(up)
+	return res
+}
+
+// Handler runs the checks on every request and responds with the report
+// as JSON: 200 if it is up, 503 if not.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status != StatusUp {
+			slog.WarnContext(req.Context(), "Health check failed", "probe", r.probe, "down", report.down())
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// down lists the components that are down, sorted.
+func (r Report) down() []string {
+	var names []string
+	for name, c := range r.Components {
+		if c.Status != StatusUp {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sonni-a/wb-service/internal/metrics"
+)
+
+func serve(t *testing.T, r *Registry) (int, Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid body %s: %v", w.Body, err)
+	}
+	return w.Code, report
+}
+
+func TestRegistry_Up(t *testing.T) {
+	r := NewRegistry("readiness", time.Second)
+	r.Register("postgres", func(context.Context) error { return nil })
+	r.Register("cache", func(context.Context) error { return nil })
+
+	code, report := serve(t, r)
+	if code != http.StatusOK || report.Status != StatusUp || len(report.Components) != 2 {
+		t.Fatalf("got %d %+v", code, report)
+	}
+	if c := report.Components["postgres"]; c.Status != StatusUp || c.Error != "" || c.LatencyMS < 0 {
+		t.Errorf("postgres = %+v", c)
+	}
+}
+
+func TestRegistry_Down(t *testing.T) {
+	r := NewRegistry("readiness", 50*time.Millisecond)
+	r.Register("postgres", func(context.Context) error { return errors.New("connection refused") })
+	r.Register("kafka_consumer", func(context.Context) error { return nil })
+	// A check that ignores its context must not hold up the probe.
+	r.Register("slow", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	code, report := serve(t, r)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("probe took %s, want about the timeout", elapsed)
+	}
+
+	if code != http.StatusServiceUnavailable || report.Status != StatusDown {
+		t.Fatalf("got %d %+v", code, report)
+	}
+	if c := report.Components["postgres"]; c.Status != StatusDown || c.Error != "connection refused" {
+		t.Errorf("postgres = %+v", c)
+	}
+	if c := report.Components["slow"]; c.Status != StatusDown || c.LatencyMS < 50 {
+		t.Errorf("slow = %+v", c)
+	}
+	if c := report.Components["kafka_consumer"]; c.Status != StatusUp {
+		t.Errorf("kafka_consumer = %+v", c)
+	}
+
+	if got := testutil.ToFloat64(metrics.HealthCheckUp.WithLabelValues("readiness", "postgres")); got != 0 {
+		t.Errorf("health_check_up{postgres} = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(metrics.HealthCheckUp.WithLabelValues("readiness", "kafka_consumer")); got != 1 {
+		t.Errorf("health_check_up{kafka_consumer} = %v, want 1", got)
+	}
+}
+
+func TestRegistry_Empty(t *testing.T) {
+	code, report := serve(t, NewRegistry("liveness", time.Second))
+	if code != http.StatusOK || report.Status != StatusUp || report.Components == nil {
+		t.Errorf("got %d %+v", code, report)
+	}
+}
+
+func TestRegistry_RegisterTwice(t *testing.T) {
+	r := NewRegistry("readiness", time.Second)
+	r.Register("postgres", func(context.Context) error { return nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice should panic")
+		}
+	}()
+	r.Register("postgres", func(context.Context) error { return nil })
+}
@@ -0,0 +1,230 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+// The CSV layout matches the one produced by the export package: one row
+// per item, with order, delivery and payment columns repeated on every row.
+// Consecutive rows sharing an order_uid are folded back into one order.
+
+type orderSetter func(o *models.Order, v string) error
+
+type itemSetter func(i *models.Item, v string) error
+
+var orderColumns = map[string]orderSetter{
+	"order_uid":          func(o *models.Order, v string) error { o.OrderUID = v; return nil },
+	"track_number":       func(o *models.Order, v string) error { o.TrackNumber = v; return nil },
+	"entry":              func(o *models.Order, v string) error { o.Entry = v; return nil },
+	"locale":             func(o *models.Order, v string) error { o.Locale = v; return nil },
+	"internal_signature": func(o *models.Order, v string) error { o.InternalSignature = optional(v); return nil },
+	"customer_id":        func(o *models.Order, v string) error { o.CustomerID = v; return nil },
+	"delivery_service":   func(o *models.Order, v string) error { o.DeliveryService = v; return nil },
+	"shardkey":           func(o *models.Order, v string) error { o.ShardKey = v; return nil },
+	"sm_id":              func(o *models.Order, v string) error { return setInt(&o.SmID, v) },
+	"date_created":       func(o *models.Order, v string) error { return setTime(&o.DateCreated, v) },
+	"oof_shard":          func(o *models.Order, v string) error { o.OofShard = v; return nil },
+
+	"delivery_name":    func(o *models.Order, v string) error { o.Delivery.Name = v; return nil },
+	"delivery_phone":   func(o *models.Order, v string) error { o.Delivery.Phone = v; return nil },
+	"delivery_zip":     func(o *models.Order, v string) error { o.Delivery.Zip = v; return nil },
+	"delivery_city":    func(o *models.Order, v string) error { o.Delivery.City = v; return nil },
+	"delivery_address": func(o *models.Order, v string) error { o.Delivery.Address = v; return nil },
+	"delivery_region":  func(o *models.Order, v string) error { o.Delivery.Region = v; return nil },
+	"delivery_email":   func(o *models.Order, v string) error { o.Delivery.Email = v; return nil },
+
+	"payment_transaction":   func(o *models.Order, v string) error { o.Payment.Transaction = v; return nil },
+	"payment_request_id":    func(o *models.Order, v string) error { o.Payment.RequestID = optional(v); return nil },
+	"payment_currency":      func(o *models.Order, v string) error { o.Payment.Currency = v; return nil },
+	"payment_provider":      func(o *models.Order, v string) error { o.Payment.Provider = v; return nil },
+	"payment_amount":        func(o *models.Order, v string) error { return setInt(&o.Payment.Amount, v) },
+	"payment_dt":            func(o *models.Order, v string) error { return setInt64(&o.Payment.PaymentDt, v) },
+	"payment_bank":          func(o *models.Order, v string) error { o.Payment.Bank = v; return nil },
+	"payment_delivery_cost": func(o *models.Order, v string) error { return setInt(&o.Payment.DeliveryCost, v) },
+	"payment_goods_total":   func(o *models.Order, v string) error { return setInt(&o.Payment.GoodsTotal, v) },
+	"payment_custom_fee":    func(o *models.Order, v string) error { return setInt(&o.Payment.CustomFee, v) },
+}
+
+var itemColumns = map[string]itemSetter{
+	"item_chrt_id":      func(i *models.Item, v string) error { return setInt64(&i.ChrtID, v) },
+	"item_track_number": func(i *models.Item, v string) error { i.TrackNumber = v; return nil },
+	"item_price":        func(i *models.Item, v string) error { return setInt(&i.Price, v) },
+	"item_rid":          func(i *models.Item, v string) error { i.RID = v; return nil },
+	"item_name":         func(i *models.Item, v string) error { i.Name = v; return nil },
+	"item_sale":         func(i *models.Item, v string) error { return setInt(&i.Sale, v) },
+	"item_size":         func(i *models.Item, v string) error { i.Size = v; return nil },
+	"item_total_price":  func(i *models.Item, v string) error { return setInt(&i.TotalPrice, v) },
+	"item_nm_id":        func(i *models.Item, v string) error { return setInt64(&i.NmID, v) },
+	"item_brand":        func(i *models.Item, v string) error { i.Brand = v; return nil },
+	"item_status":       func(i *models.Item, v string) error { return setInt(&i.Status, v) },
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+	uidCol int
+
+	pending     []string
+	pendingLine int
+	pendingErr  error
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := &csvReader{r: csv.NewReader(r), uidCol: -1}
+
+	header, err := cr.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+
+	for i, name := range header {
+		if name == "order_uid" {
+			cr.uidCol = i
+		}
+	}
+	if cr.uidCol < 0 {
+		return nil, errors.New("CSV header has no order_uid column")
+	}
+	cr.header = header
+
+	return cr, nil
+}
+
+func (cr *csvReader) read() {
+	row, err := cr.r.Read()
+	cr.pending, cr.pendingErr = row, err
+
+	var parseErr *csv.ParseError
+	switch {
+	case err == nil:
+		cr.pendingLine, _ = cr.r.FieldPos(0)
+	case errors.As(err, &parseErr):
+		cr.pendingLine = parseErr.StartLine
+	}
+}
+
+func (cr *csvReader) Next() (Record, error) {
+	if cr.pending == nil && cr.pendingErr == nil {
+		cr.read()
+	}
+
+	if errors.Is(cr.pendingErr, io.EOF) {
+		return Record{}, io.EOF
+	}
+
+	rec := Record{Line: cr.pendingLine, Order: &models.Order{}}
+
+	if cr.pendingErr != nil {
+		var parseErr *csv.ParseError
+		if !errors.As(cr.pendingErr, &parseErr) {
+			return Record{}, cr.pendingErr
+		}
+		rec.Err = fmt.Errorf("invalid CSV row: %w", cr.pendingErr)
+		cr.pending, cr.pendingErr = nil, nil
+		return rec, nil
+	}
+
+	uid := cr.pending[cr.uidCol]
+	rec.Err = cr.applyOrder(rec.Order, cr.pending)
+
+	for cr.pending != nil && cr.pendingErr == nil && cr.pending[cr.uidCol] == uid {
+		if err := cr.applyItem(rec.Order, cr.pending); err != nil && rec.Err == nil {
+			rec.Err = err
+		}
+		cr.read()
+	}
+
+	return rec, nil
+}
+
+func (cr *csvReader) applyOrder(o *models.Order, row []string) error {
+	for i, name := range cr.header {
+		set, ok := orderColumns[name]
+		if !ok {
+			continue
+		}
+		if err := set(o, row[i]); err != nil {
+			return fmt.Errorf("column %s: %w", name, err)
+		}
+	}
+
+	o.Delivery.OrderUID = o.OrderUID
+	o.Payment.OrderUID = o.OrderUID
+	return nil
+}
+
+func (cr *csvReader) applyItem(o *models.Order, row []string) error {
+	var (
+		item  models.Item
+		empty = true
+	)
+
+	for i, name := range cr.header {
+		set, ok := itemColumns[name]
+		if !ok || row[i] == "" {
+			continue
+		}
+		empty = false
+		if err := set(&item, row[i]); err != nil {
+			return fmt.Errorf("column %s: %w", name, err)
+		}
+	}
+
+	if !empty {
+		item.OrderUID = o.OrderUID
+		o.Items = append(o.Items, item)
+	}
+	return nil
+}
+
+// optional maps an empty cell of a nullable column to nil, as the export
+// writes nil as an empty cell.
+func optional(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func setInt(dst *int, v string) error {
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*dst = n
+	return nil
+}
+
+func setInt64(dst *int64, v string) error {
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*dst = n
+	return nil
+}
+
+func setTime(dst *time.Time, v string) error {
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", v)
+	}
+	*dst = t
+	return nil
+}
@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+	"github.com/sonni-a/wb-service/internal/service"
+	"github.com/sonni-a/wb-service/internal/validator"
+)
+
+const DefaultBatchSize = 100
+
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatNDJSON, FormatCSV:
+		return f, nil
+	case "":
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported import format: %s", s)
+	}
+}
+
+// Record is a single order read from the input. Line points at the line the
+// order starts on; Err is set when the record could not be decoded.
+type Record struct {
+	Line  int
+	Order *models.Order
+	Err   error
+}
+
+// Reader yields records one at a time and returns io.EOF when the input is exhausted.
+type Reader interface {
+	Next() (Record, error)
+}
+
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONReader(r), nil
+	case FormatCSV:
+		return newCSVReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+}
+
+type Status string
+
+const (
+	StatusAccepted  Status = "accepted"
+	StatusDuplicate Status = "duplicate"
+	StatusRejected  Status = "rejected"
+)
+
+type RowResult struct {
+	Line     int    `json:"line"`
+	OrderUID string `json:"order_uid,omitempty"`
+	Status   Status `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Report summarises an import. Rows lists only duplicate and rejected
+// records; accepted ones are just counted.
+type Report struct {
+	Accepted   int         `json:"accepted"`
+	Duplicates int         `json:"duplicates"`
+	Rejected   int         `json:"rejected"`
+	Rows       []RowResult `json:"rows"`
+}
+
+func (rep *Report) add(res RowResult) {
+	switch res.Status {
+	case StatusAccepted:
+		rep.Accepted++
+		return
+	case StatusDuplicate:
+		rep.Duplicates++
+	case StatusRejected:
+		rep.Rejected++
+	}
+	rep.Rows = append(rep.Rows, res)
+}
+
+type BatchInserter interface {
+	InsertOrders(ctx context.Context, orders []*models.Order) ([]error, error)
+}
+
+// Run reads orders from r, validates each of them and inserts valid ones
+// into dst in batches of batchSize. The returned report is populated even
+// when Run fails midway and reflects everything processed so far.
+func Run(ctx context.Context, dst BatchInserter, format Format, r io.Reader, batchSize int) (*Report, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	reader, err := NewReader(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Rows: []RowResult{}}
+	batch := make([]Record, 0, batchSize)
+
+	// Rejected records are reported right away while duplicates only show up
+	// once their batch is flushed, so restore input order at the end.
+	defer func() {
+		slices.SortStableFunc(report.Rows, func(a, b RowResult) int {
+			return cmp.Compare(a.Line, b.Line)
+		})
+	}()
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		orders := make([]*models.Order, len(batch))
+		for i, rec := range batch {
+			orders[i] = rec.Order
+		}
+
+		results, err := dst.InsertOrders(ctx, orders)
+		if err != nil {
+			return fmt.Errorf("insert batch starting at line %d: %w", batch[0].Line, err)
+		}
+
+		for i, rec := range batch {
+			report.add(resultFor(rec, results[i]))
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("read input: %w", err)
+		}
+
+		if rec.Err == nil {
+			rec.Err = validator.ValidateOrder(rec.Order)
+		}
+		if rec.Err != nil {
+			report.add(resultFor(rec, rec.Err))
+			continue
+		}
+
+		batch = append(batch, rec)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+func resultFor(rec Record, err error) RowResult {
+	res := RowResult{Line: rec.Line, Status: StatusAccepted}
+	if rec.Order != nil {
+		res.OrderUID = rec.Order.OrderUID
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrOrderAlreadyExists), errors.Is(err, service.ErrOrderAlreadyExists):
+		res.Status = StatusDuplicate
+		res.Reason = "order already exists"
+	default:
+		res.Status = StatusRejected
+		res.Reason = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/export"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/repository"
+)
+
+type fakeInserter struct {
+	existing map[string]bool
+	batches  int
+}
+
+func (f *fakeInserter) InsertOrders(_ context.Context, orders []*models.Order) ([]error, error) {
+	f.batches++
+	results := make([]error, len(orders))
+	for i, o := range orders {
+		if f.existing[o.OrderUID] {
+			results[i] = repository.ErrOrderAlreadyExists
+			continue
+		}
+		f.existing[o.OrderUID] = true
+	}
+	return results, nil
+}
+
+type fakeStreamer struct {
+	orders []*models.Order
+}
+
+func (f *fakeStreamer) StreamOrders(_ context.Context, _ repository.OrderFilter, fn func(*models.Order) error) error {
+	for _, o := range f.orders {
+		if err := fn(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validOrder(uid string) *models.Order {
+	empty := ""
+	return &models.Order{
+		OrderUID:          uid,
+		TrackNumber:       "WBIL12345678",
+		Entry:             "WBIL",
+		Locale:            "en",
+		InternalSignature: &empty,
+		CustomerID:        "customer-1",
+		DeliveryService:   "meest",
+		ShardKey:          "1",
+		SmID:              1,
+		DateCreated:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		OofShard:          "1",
+		Delivery: models.Delivery{
+			OrderUID: uid,
+			Name:     "John Doe",
+			Phone:    "+12345678901",
+			Zip:      "12345",
+			City:     "Moscow",
+			Address:  "Red Square 1",
+			Region:   "Moscow",
+			Email:    "john@example.com",
+		},
+		Payment: models.Payment{
+			OrderUID:    uid,
+			Transaction: uid,
+			RequestID:   &empty,
+			Currency:    "USD",
+			Provider:    "wbpay",
+			Amount:      1000,
+			PaymentDt:   1700000000,
+			Bank:        "AlphaBank",
+			GoodsTotal:  900,
+		},
+		Items: []models.Item{
+			{OrderUID: uid, ChrtID: 1, TrackNumber: "WBIL12345678", Price: 500, RID: "rid-1",
+				Name: "T-Shirt", Size: "M", TotalPrice: 500, NmID: 1, Brand: "Brand", Status: 202},
+			{OrderUID: uid, ChrtID: 2, TrackNumber: "WBIL12345678", Price: 400, RID: "rid-2",
+				Name: "Socks", Size: "L", TotalPrice: 400, NmID: 2, Brand: "Brand", Status: 202},
+		},
+	}
+}
+
+func TestRun_NDJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := export.Run(context.Background(), &fakeStreamer{orders: []*models.Order{
+		validOrder("a"), validOrder("b"), validOrder("a"),
+	}}, repository.OrderFilter{}, export.FormatNDJSON, &buf); err != nil {
+		t.Fatalf("prepare input: %v", err)
+	}
+	buf.WriteString("{not json}\n")
+	buf.WriteString(`{"order_uid":"c"}` + "\n")
+
+	dst := &fakeInserter{existing: map[string]bool{}}
+	report, err := Run(context.Background(), dst, FormatNDJSON, &buf, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Accepted != 2 || report.Duplicates != 1 || report.Rejected != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if dst.batches != 2 {
+		t.Fatalf("expected 2 batches, got %d", dst.batches)
+	}
+
+	last := report.Rows[len(report.Rows)-1]
+	if last.Line != 5 || last.OrderUID != "c" || !strings.Contains(last.Reason, "track_number") {
+		t.Fatalf("unexpected rejected row: %+v", last)
+	}
+}
+
+func TestRun_CSVRoundTripsExport(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := export.Run(context.Background(), &fakeStreamer{orders: []*models.Order{
+		validOrder("a"), validOrder("b"),
+	}}, repository.OrderFilter{}, export.FormatCSV, &buf); err != nil {
+		t.Fatalf("prepare input: %v", err)
+	}
+
+	reader, err := NewReader(FormatCSV, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec, err := reader.Next()
+	if err != nil || rec.Err != nil {
+		t.Fatalf("unexpected error: %v, %v", err, rec.Err)
+	}
+	if rec.Line != 2 || rec.Order.OrderUID != "a" || len(rec.Order.Items) != 2 {
+		t.Fatalf("unexpected first record: line %d, %+v", rec.Line, rec.Order)
+	}
+	if !rec.Order.DateCreated.Equal(validOrder("a").DateCreated) {
+		t.Fatalf("date_created not preserved: %v", rec.Order.DateCreated)
+	}
+
+	rec, err = reader.Next()
+	if err != nil || rec.Order.OrderUID != "b" || rec.Line != 4 {
+		t.Fatalf("unexpected second record: %v, %+v", err, rec)
+	}
+
+	if _, err := reader.Next(); err == nil {
+		t.Fatalf("expected EOF")
+	}
+}
+
+func TestRun_CSVKeepsNilFields(t *testing.T) {
+	want := validOrder("a")
+	want.InternalSignature, want.Payment.RequestID = nil, nil
+
+	var buf bytes.Buffer
+	if _, err := export.Run(context.Background(), &fakeStreamer{orders: []*models.Order{want}},
+		repository.OrderFilter{}, export.FormatCSV, &buf); err != nil {
+		t.Fatalf("prepare input: %v", err)
+	}
+
+	reader, err := NewReader(FormatCSV, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rec, err := reader.Next()
+	if err != nil || rec.Err != nil {
+		t.Fatalf("unexpected error: %v, %v", err, rec.Err)
+	}
+	if !reflect.DeepEqual(rec.Order, want) {
+		t.Fatalf("round trip changed the order:\ngot  %+v\nwant %+v", rec.Order, want)
+	}
+}
@@ -0,0 +1,45 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+type ndjsonReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	return &ndjsonReader{r: bufio.NewReader(r)}
+}
+
+func (nr *ndjsonReader) Next() (Record, error) {
+	for {
+		data, err := nr.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return Record{}, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Record{}, err
+		}
+		nr.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		rec := Record{Line: nr.line, Order: &models.Order{}}
+		if err := json.Unmarshal(data, rec.Order); err != nil {
+			rec.Err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		return rec, nil
+	}
+}
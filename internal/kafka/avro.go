@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+// AvroCodec handles Confluent wire-format Avro. Decoding uses whatever writer
+// schema the message references; encoding registers schemas/order.avsc under
+// the topic's subject.
+type AvroCodec struct {
+	registry SchemaRegistry
+	schema   string
+
+	mu     sync.Mutex
+	codecs map[int]*goavro.Codec
+}
+
+var _ Codec = (*AvroCodec)(nil)
+
+func NewAvroCodec(registry SchemaRegistry) *AvroCodec {
+	schema, err := schemaFS.ReadFile("schemas/order.avsc")
+	if err != nil {
+		panic("failed to load embedded Avro schema: " + err.Error())
+	}
+
+	return &AvroCodec{
+		registry: registry,
+		schema:   string(schema),
+		codecs:   make(map[int]*goavro.Codec),
+	}
+}
+
+func (c *AvroCodec) Name() string        { return "Avro" }
+func (c *AvroCodec) ContentType() string { return ContentTypeAvro }
+
+func (c *AvroCodec) Encode(ctx context.Context, topic string, order *models.Order) ([]byte, error) {
+	id, err := c.registry.Register(ctx, subjectForTopic(topic), Schema{Type: SchemaTypeAvro, Schema: c.schema})
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := c.codecFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := json.Marshal(order)
+	if err != nil {
+		return nil, err
+	}
+
+	native, _, err := codec.NativeFromTextual(text)
+	if err != nil {
+		return nil, fmt.Errorf("convert order to Avro: %w", err)
+	}
+
+	return codec.BinaryFromNative(appendWireHeader(nil, id), native)
+}
+
+func (c *AvroCodec) Decode(ctx context.Context, data []byte, order *models.Order) error {
+	id, payload, err := parseWireHeader(data)
+	if err != nil {
+		return err
+	}
+
+	codec, err := c.codecFor(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	native, _, err := codec.NativeFromBinary(payload)
+	if err != nil {
+		return fmt.Errorf("decode Avro payload: %w", err)
+	}
+
+	text, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return fmt.Errorf("convert Avro payload: %w", err)
+	}
+
+	return json.Unmarshal(text, order)
+}
+
+// codecFor returns the codec of schema id, fetching it from the registry
+// unless it is cached. The lock is not held during the fetch, so that a slow
+// registry does not hold up messages of cached schemas; concurrent misses may
+// fetch the same schema twice.
+func (c *AvroCodec) codecFor(ctx context.Context, id int) (*goavro.Codec, error) {
+	c.mu.Lock()
+	codec, ok := c.codecs[id]
+	c.mu.Unlock()
+	if ok {
+		return codec, nil
+	}
+
+	schema, err := c.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Type != SchemaTypeAvro {
+		return nil, fmt.Errorf("schema %d is %s, not Avro", id, schema.Type)
+	}
+
+	// Standard JSON keeps nullable unions as plain values, matching the
+	// encoding/json representation of models.Order.
+	codec, err = goavro.NewCodecForStandardJSONFull(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("parse Avro schema %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.codecs[id] = codec
+	c.mu.Unlock()
+	return codec, nil
+}
@@ -0,0 +1,139 @@
+package kafka
+
+import (
+	"context"
+	"embed"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/strictjson"
+)
+
+const (
+	HeaderContentType = "content-type"
+
+	ContentTypeJSON     = "application/json"
+	ContentTypeAvro     = "application/vnd.confluent.avro"
+	ContentTypeProtobuf = "application/vnd.confluent.protobuf"
+)
+
+//go:embed schemas
+var schemaFS embed.FS
+
+var errInvalidWireFormat = errors.New("invalid Confluent wire format")
+
+// Codec converts orders to and from Kafka message values.
+type Codec interface {
+	Name() string
+	ContentType() string
+	Encode(ctx context.Context, topic string, order *models.Order) ([]byte, error)
+	Decode(ctx context.Context, data []byte, order *models.Order) error
+}
+
+// JSONCodec handles plain JSON. In strict mode unknown fields, type
+// mismatches and nulls in non-nullable fields are rejected with their
+// JSON paths instead of being silently ignored.
+type JSONCodec struct {
+	Strict bool
+}
+
+func (JSONCodec) Name() string        { return "JSON" }
+func (JSONCodec) ContentType() string { return ContentTypeJSON }
+
+func (JSONCodec) Encode(_ context.Context, _ string, order *models.Order) ([]byte, error) {
+	return json.Marshal(order)
+}
+
+func (c JSONCodec) Decode(_ context.Context, data []byte, order *models.Order) error {
+	if c.Strict {
+		return strictjson.Unmarshal(data, order)
+	}
+	return json.Unmarshal(data, order)
+}
+
+// Codecs picks a codec for an incoming message based on its content-type
+// header. Messages without the header are treated as JSON.
+type Codecs struct {
+	byType     map[string]Codec
+	strictJSON atomic.Bool
+}
+
+// NewCodecs always supports JSON; Avro and Protobuf are only enabled when a
+// schema registry is available.
+func NewCodecs(registry SchemaRegistry, strictJSON bool) *Codecs {
+	c := &Codecs{byType: map[string]Codec{ContentTypeJSON: JSONCodec{}}}
+	c.strictJSON.Store(strictJSON)
+
+	if registry != nil {
+		c.byType[ContentTypeAvro] = NewAvroCodec(registry)
+		c.byType[ContentTypeProtobuf] = NewProtobufCodec(registry)
+	}
+
+	return c
+}
+
+// SetStrictJSON switches strict decoding for JSON messages picked from now on.
+func (c *Codecs) SetStrictJSON(strict bool) {
+	c.strictJSON.Store(strict)
+}
+
+func (c *Codecs) ForMessage(m kafka.Message) (Codec, error) {
+	ct := headerValue(m, HeaderContentType)
+	if ct == "" {
+		return c.withSettings(c.byType[ContentTypeJSON]), nil
+	}
+
+	// Ignore parameters such as "; charset=utf-8".
+	ct, _, _ = strings.Cut(ct, ";")
+	if codec, ok := c.byType[strings.TrimSpace(strings.ToLower(ct))]; ok {
+		return c.withSettings(codec), nil
+	}
+	return nil, fmt.Errorf("unsupported content type: %s", ct)
+}
+
+// ByName returns a codec by its short name: json, avro or protobuf.
+func (c *Codecs) ByName(name string) (Codec, error) {
+	for _, codec := range c.byType {
+		if strings.EqualFold(codec.Name(), name) {
+			return c.withSettings(codec), nil
+		}
+	}
+	return nil, fmt.Errorf("message format %q is not available", name)
+}
+
+func (c *Codecs) withSettings(codec Codec) Codec {
+	if jc, ok := codec.(JSONCodec); ok {
+		jc.Strict = c.strictJSON.Load()
+		return jc
+	}
+	return codec
+}
+
+func headerValue(m kafka.Message, key string) string {
+	for _, h := range m.Headers {
+		if strings.EqualFold(h.Key, key) {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// appendWireHeader writes the Confluent wire format prefix: a zero magic
+// byte followed by the big-endian schema ID.
+func appendWireHeader(dst []byte, schemaID int) []byte {
+	dst = append(dst, 0)
+	return binary.BigEndian.AppendUint32(dst, uint32(schemaID))
+}
+
+func parseWireHeader(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != 0 {
+		return 0, nil, errInvalidWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
@@ -0,0 +1,384 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+// fakeRegistry is an in-process stand-in for the Confluent schema registry
+// implementing just the endpoints RegistryClient uses.
+type fakeRegistry struct {
+	mu      sync.Mutex
+	schemas []registrySchema
+	lookups int
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *httptest.Server) {
+	t.Helper()
+
+	reg := &fakeRegistry{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /subjects/{subject}/versions", func(w http.ResponseWriter, r *http.Request) {
+		var req registrySchema
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		reg.mu.Lock()
+		defer reg.mu.Unlock()
+
+		id := -1
+		for i, s := range reg.schemas {
+			if s == req {
+				id = i + 1
+			}
+		}
+		if id < 0 {
+			reg.schemas = append(reg.schemas, req)
+			id = len(reg.schemas)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	})
+
+	mux.HandleFunc("GET /schemas/ids/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+
+		reg.mu.Lock()
+		defer reg.mu.Unlock()
+
+		reg.lookups++
+		if id < 1 || id > len(reg.schemas) {
+			http.Error(w, `{"error_code":40403,"message":"Schema not found"}`, http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(reg.schemas[id-1])
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return reg, srv
+}
+
+func testOrder() *models.Order {
+	sig := "sig"
+	return &models.Order{
+		OrderUID:          "b563feb7b2b84b6test",
+		TrackNumber:       "WBILMTESTTRACK",
+		Entry:             "WBIL",
+		Locale:            "en",
+		InternalSignature: &sig,
+		CustomerID:        "test",
+		DeliveryService:   "meest",
+		ShardKey:          "9",
+		SmID:              99,
+		DateCreated:       time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		OofShard:          "1",
+		Delivery: models.Delivery{
+			OrderUID: "b563feb7b2b84b6test",
+			Name:     "Test Testov",
+			Phone:    "+9720000000",
+			Zip:      "2639809",
+			City:     "Kiryat Mozkin",
+			Address:  "Ploshad Mira 15",
+			Region:   "Kraiot",
+			Email:    "test@gmail.com",
+		},
+		Payment: models.Payment{
+			OrderUID:     "b563feb7b2b84b6test",
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []models.Item{
+			{
+				OrderUID:    "b563feb7b2b84b6test",
+				ChrtID:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       453,
+				RID:         "ab4219087a764ae0btest",
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  317,
+				NmID:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	reg, srv := newFakeRegistry(t)
+	codecs := NewCodecs(NewRegistryClient(srv.URL), false)
+
+	for _, name := range []string{"json", "avro", "protobuf"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			order := testOrder()
+
+			codec, err := codecs.ByName(name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := codec.Encode(ctx, "orders", order)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			msg := kafka.Message{
+				Value:   data,
+				Headers: []kafka.Header{{Key: HeaderContentType, Value: []byte(codec.ContentType())}},
+			}
+			decoder, err := codecs.ForMessage(msg)
+			if err != nil {
+				t.Fatalf("select decoder: %v", err)
+			}
+			if decoder != codec {
+				t.Fatalf("expected %s decoder, got %s", codec.Name(), decoder.Name())
+			}
+
+			var got models.Order
+			if err := decoder.Decode(ctx, msg.Value, &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(&got, order) {
+				t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, *order)
+			}
+		})
+	}
+
+	if len(reg.schemas) != 2 {
+		t.Fatalf("expected Avro and Protobuf schemas to be registered, got %d", len(reg.schemas))
+	}
+	if reg.lookups != 0 {
+		t.Fatalf("expected schemas registered by this client to be served from cache, got %d lookups", reg.lookups)
+	}
+}
+
+func TestAvroCodec_DecodeFetchesAndCachesSchema(t *testing.T) {
+	reg, srv := newFakeRegistry(t)
+	ctx := context.Background()
+
+	data, err := NewAvroCodec(NewRegistryClient(srv.URL)).Encode(ctx, "orders", testOrder())
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	consumerCodec := NewAvroCodec(NewRegistryClient(srv.URL))
+	for i := 0; i < 3; i++ {
+		var got models.Order
+		if err := consumerCodec.Decode(ctx, data, &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got.InternalSignature == nil || *got.InternalSignature != "sig" || got.Payment.RequestID != nil {
+			t.Fatalf("nullable fields not preserved: %v, %v", got.InternalSignature, got.Payment.RequestID)
+		}
+	}
+
+	if reg.lookups != 1 {
+		t.Fatalf("expected a single registry lookup, got %d", reg.lookups)
+	}
+}
+
+func TestCodecs_Selection(t *testing.T) {
+	withoutRegistry := NewCodecs(nil, false)
+
+	codec, err := withoutRegistry.ForMessage(kafka.Message{})
+	if err != nil || codec.Name() != "JSON" {
+		t.Fatalf("expected JSON for messages without content type, got %v, %v", codec, err)
+	}
+
+	_, err = withoutRegistry.ForMessage(kafka.Message{
+		Headers: []kafka.Header{{Key: "Content-Type", Value: []byte(ContentTypeAvro)}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unsupported content type") {
+		t.Fatalf("expected Avro to be unavailable without a registry, got %v", err)
+	}
+}
+
+func TestProtobufCodec_RejectsWrongWireFormat(t *testing.T) {
+	_, srv := newFakeRegistry(t)
+	codec := NewProtobufCodec(NewRegistryClient(srv.URL))
+
+	var order models.Order
+	if err := codec.Decode(context.Background(), []byte(`{"order_uid":"x"}`), &order); err == nil {
+		t.Fatalf("expected error for non wire-format payload")
+	}
+	if err := codec.Decode(context.Background(), appendWireHeader(nil, 42), &order); err == nil {
+		t.Fatalf("expected error for unknown schema id")
+	}
+}
+
+func TestParseMessageIndexes(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []int
+		wantErr bool
+	}{
+		{name: "short form", data: []byte{0, 0xff}, want: []int{0}},
+		{name: "path", data: []byte{4, 2, 6, 0xff}, want: []int{1, 3}},
+		{name: "truncated", data: []byte{4, 2}, wantErr: true},
+		{name: "negative count", data: []byte{1}, wantErr: true},
+		// A count of 2^62 must be rejected before it sizes an allocation.
+		{name: "huge count", data: binary.AppendVarint(nil, 1<<62), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := parseMessageIndexes(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(rest, []byte{0xff}) {
+				t.Fatalf("got %v, %v, %v", got, rest, err)
+			}
+		})
+	}
+}
+
+// blockingRegistry holds up lookups of schema block until release is
+// closed.
+type blockingRegistry struct {
+	SchemaRegistry
+	block   int
+	release chan struct{}
+}
+
+func (r *blockingRegistry) SchemaByID(ctx context.Context, id int) (Schema, error) {
+	if id == r.block {
+		<-r.release
+	}
+	return r.SchemaRegistry.SchemaByID(ctx, id)
+}
+
+func TestAvroCodec_SlowLookupDoesNotBlockCachedSchemas(t *testing.T) {
+	_, srv := newFakeRegistry(t)
+	ctx := context.Background()
+
+	data, err := NewAvroCodec(NewRegistryClient(srv.URL)).Encode(ctx, "orders", testOrder())
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	reg := &blockingRegistry{SchemaRegistry: NewRegistryClient(srv.URL), block: 2, release: make(chan struct{})}
+	defer close(reg.release)
+	codec := NewAvroCodec(reg)
+	var order models.Order
+	if err := codec.Decode(ctx, data, &order); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	go func() {
+		var order models.Order
+		_ = codec.Decode(ctx, appendWireHeader(nil, 2), &order)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		var order models.Order
+		done <- codec.Decode(ctx, data, &order)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("decoding with a cached schema waited for another schema's lookup")
+	}
+}
+
+func TestJSONCodec_Strict(t *testing.T) {
+	data := []byte(`{"order_uid":"x","delivery_servise":"meest"}`)
+
+	var lenient models.Order
+	if err := (JSONCodec{}).Decode(context.Background(), data, &lenient); err != nil {
+		t.Fatalf("lenient decode: %v", err)
+	}
+
+	var strict models.Order
+	err := (JSONCodec{Strict: true}).Decode(context.Background(), data, &strict)
+	if err == nil || !strings.Contains(err.Error(), "$.delivery_servise") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestCodecs_SetStrictJSON(t *testing.T) {
+	codecs := NewCodecs(nil, false)
+	data := []byte(`{"order_uid":"x","delivery_servise":"meest"}`)
+
+	decode := func() error {
+		codec, err := codecs.ForMessage(kafka.Message{Value: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var order models.Order
+		return codec.Decode(context.Background(), data, &order)
+	}
+
+	if err := decode(); err != nil {
+		t.Fatalf("lenient decode: %v", err)
+	}
+	codecs.SetStrictJSON(true)
+	if err := decode(); err == nil {
+		t.Fatal("expected strict decode to fail after SetStrictJSON(true)")
+	}
+}
+
+func TestCodecs_NullableFieldsRoundTrip(t *testing.T) {
+	_, srv := newFakeRegistry(t)
+	codecs := NewCodecs(NewRegistryClient(srv.URL), true)
+	empty := ""
+
+	for _, name := range []string{"json", "avro", "protobuf"} {
+		for _, value := range []*string{nil, &empty} {
+			t.Run(fmt.Sprintf("%s/nil=%t", name, value == nil), func(t *testing.T) {
+				ctx := context.Background()
+				codec, _ := codecs.ByName(name)
+
+				order := testOrder()
+				order.InternalSignature = value
+				order.Payment.RequestID = value
+
+				data, err := codec.Encode(ctx, "orders", order)
+				if err != nil {
+					t.Fatalf("encode: %v", err)
+				}
+
+				var got models.Order
+				if err := codec.Decode(ctx, data, &got); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if !reflect.DeepEqual(got.InternalSignature, value) || !reflect.DeepEqual(got.Payment.RequestID, value) {
+					t.Fatalf("expected %v, got %v and %v", value, got.InternalSignature, got.Payment.RequestID)
+				}
+			})
+		}
+	}
+}
@@ -4,142 +4,262 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/health"
+	"github.com/sonni-a/wb-service/internal/logging"
 	"github.com/sonni-a/wb-service/internal/metrics"
 	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/pii"
 	"github.com/sonni-a/wb-service/internal/service"
 	"github.com/sonni-a/wb-service/internal/validator"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Reasons a message is rejected, used as the reason label of the processing
+// error and DLQ metrics.
+const (
+	reasonContentType = "unsupported_content_type"
+	reasonSchema      = "schema_validation"
+	reasonDecode      = "decode"
+	reasonValidation  = "validation"
+	reasonDBWrite     = "db_write"
+)
+
+// readTimeout bounds a read, so that the loop comes round on an idle topic;
+// retryDelay is the pause after a failed read.
+const (
+	readTimeout = 10 * time.Second
+	retryDelay  = 2 * time.Second
+)
+
+// messageReader is the part of *kafka.Reader the consumer uses.
+type messageReader interface {
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+	Stats() kafka.ReaderStats
+	Config() kafka.ReaderConfig
+	Close() error
+}
+
 type Consumer struct {
-	reader    *kafka.Reader
+	reader    messageReader
 	dlqWriter *kafka.Writer
 	svc       service.OrderServiceInterface
+	codecs    *Codecs
+
+	// running is set while Consume runs and ready once the reader has read
+	// a message or fetched from an idle topic; heartbeat is the Unix time in
+	// nanoseconds of the last time it did. See Check.
+	running   atomic.Bool
+	ready     atomic.Bool
+	heartbeat atomic.Int64
 }
 
-func NewConsumer(brokers []string, topic, groupID string, svc service.OrderServiceInterface) *Consumer {
+// NewConsumer reads orders from topic as a member of groupID and sends
+// rejected messages to dlqTopic. Both connect to brokers with sec.
+func NewConsumer(brokers []string, topic, dlqTopic, groupID string, sec Security, svc service.OrderServiceInterface, codecs *Codecs) *Consumer {
 	r := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: brokers,
 		Topic:   topic,
 		GroupID: groupID,
+		Dialer:  sec.dialer(),
 	})
 
 	dlqWriter := kafka.NewWriter(kafka.WriterConfig{
 		Brokers:  brokers,
-		Topic:    "orders-dlq",
+		Topic:    dlqTopic,
 		Balancer: &kafka.LeastBytes{},
+		Dialer:   sec.dialer(),
 	})
 
 	return &Consumer{
 		reader:    r,
 		dlqWriter: dlqWriter,
 		svc:       svc,
+		codecs:    codecs,
 	}
 }
 
 func (c *Consumer) Consume(ctx context.Context) error {
-	log.Println("Kafka consumer starting...")
+	slog.InfoContext(ctx, "Kafka consumer starting", logging.KeyTopic, c.reader.Config().Topic)
+	c.running.Store(true)
+	defer func() {
+		c.running.Store(false)
+		c.ready.Store(false)
+	}()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			slog.InfoContext(ctx, "Kafka consumer stopped")
+			return nil
 		default:
 		}
 
-		testCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-		_, err := c.reader.ReadMessage(testCtx)
+		msgCtx, cancel := context.WithTimeout(ctx, readTimeout)
+		m, err := c.reader.ReadMessage(msgCtx)
 		cancel()
+		// The stats cover the fetches since the previous read.
+		stats := c.reader.Stats()
+		metrics.KafkaConsumerLag.WithLabelValues(stats.Topic).Set(float64(stats.Lag))
 
-		if err == nil {
-			log.Println("Kafka consumer ready.")
-			break
+		switch {
+		case err == nil:
+			c.progress(ctx)
+			c.handleMessage(ctx, m)
+		case errors.Is(err, context.DeadlineExceeded) && stats.Fetches > 0:
+			// The reader has joined the group and fetches, the topic is
+			// just idle.
+			c.progress(ctx)
+			slog.DebugContext(ctx, "Kafka read timeout, retrying")
+		case ctx.Err() != nil:
+			// Shutting down; the loop returns.
+		default:
+			if c.ready.Load() {
+				slog.ErrorContext(ctx, "Kafka read error", "error", err)
+			} else {
+				slog.InfoContext(ctx, "Kafka not ready, retrying", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
 		}
+	}
+}
 
-		log.Println("Kafka not ready, retrying...")
-		time.Sleep(2 * time.Second)
+// progress records that the reader works: it read a message or waited for
+// one with nothing to read.
+func (c *Consumer) progress(ctx context.Context) {
+	c.beat()
+	if !c.ready.Swap(true) {
+		slog.InfoContext(ctx, "Kafka consumer ready")
 	}
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Kafka consumer stopped")
-			log.Println("Kafka consumer exited gracefully")
-			return nil
-		default:
+func (c *Consumer) beat() {
+	c.heartbeat.Store(time.Now().UnixNano())
+}
+
+// Check returns the readiness check of the consumer. It fails when Consume
+// is not running, until the reader has read a message or fetched from an
+// idle topic, and when it has done neither for stallAfter, as when
+// processing a message hangs or every read fails. Reads time out well within
+// a minute, so an idle topic does not count as stuck.
+func (c *Consumer) Check(stallAfter time.Duration) health.Check {
+	return func(context.Context) error {
+		if !c.running.Load() {
+			return errors.New("consumer is not running")
+		}
+		if !c.ready.Load() {
+			return errors.New("consumer has not connected to Kafka yet")
+		}
+		if idle := time.Since(time.Unix(0, c.heartbeat.Load())); idle > stallAfter {
+			return fmt.Errorf("no progress for %s", idle.Round(time.Second))
 		}
+		return nil
+	}
+}
 
-		msgCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-		m, err := c.reader.ReadMessage(msgCtx)
-		cancel()
+func (c *Consumer) handleMessage(ctx context.Context, m kafka.Message) {
+	start := time.Now()
+	ctx, span := startConsumerSpan(ctx, m)
+	defer span.End()
+	ctx = logging.With(ctx,
+		logging.KeyTopic, m.Topic,
+		logging.KeyPartition, m.Partition,
+		logging.KeyOffset, m.Offset,
+	)
+	ctx = audit.WithActor(ctx, audit.Actor{Name: c.reader.Config().GroupID, Source: audit.SourceKafka})
 
-		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
-				log.Println("Kafka read timeout, retrying...")
-				continue
-			}
-			log.Printf("Kafka read error: %v", err)
-			continue
-		}
+	codec, err := c.codecs.ForMessage(m)
+	if err != nil {
+		slog.WarnContext(ctx, "Cannot decode message", "error", err)
+		c.sendToDLQ(ctx, m, reasonContentType, "unsupported content type")
+		return
+	}
 
-		var order models.Order
-		if err := json.Unmarshal(m.Value, &order); err != nil {
-			log.Printf("Invalid JSON: %v", err)
-			metrics.KafkaProcessingErrorsTotal.Inc()
-			c.sendToDLQ(ctx, m, "invalid JSON")
-			continue
+	// Plain JSON carries no schema of its own, so check it against the
+	// published order schema before decoding.
+	if codec.ContentType() == ContentTypeJSON {
+		if err := validator.ValidateOrderJSON(m.Value); err != nil {
+			slog.WarnContext(ctx, "Order does not match schema", "error", err)
+			c.sendToDLQ(ctx, m, reasonSchema, "schema validation failed: "+err.Error())
+			return
 		}
+	}
 
-		if err := validator.ValidateOrder(&order); err != nil {
-			log.Printf("Invalid order (%s): %v", order.OrderUID, err)
-			metrics.KafkaProcessingErrorsTotal.Inc()
-			c.sendToDLQ(ctx, m, "validation failed")
-			continue
-		}
+	var order models.Order
+	if err := codec.Decode(ctx, m.Value, &order); err != nil {
+		slog.WarnContext(ctx, "Invalid message", "format", codec.Name(), "error", err)
+		c.sendToDLQ(ctx, m, reasonDecode, fmt.Sprintf("invalid %s: %v", codec.Name(), err))
+		return
+	}
+	ctx = logging.With(ctx, logging.KeyOrderUID, order.OrderUID)
+	span.SetAttributes(attribute.String(logging.KeyOrderUID, order.OrderUID))
 
-		if err := c.svc.CreateOrder(ctx, &order); err != nil {
-			if errors.Is(err, service.ErrOrderAlreadyExists) {
-				log.Printf("Order %s already exists, skipping duplicate message", order.OrderUID)
-				metrics.KafkaMessagesProcessedTotal.Inc()
-				continue
-			}
+	if err := validator.ValidateOrder(&order); err != nil {
+		slog.WarnContext(ctx, "Invalid order", "error", err)
+		c.sendToDLQ(ctx, m, reasonValidation, "validation failed: "+err.Error())
+		return
+	}
 
-			log.Printf("Failed to save order %s: %v", order.OrderUID, err)
-			metrics.KafkaProcessingErrorsTotal.Inc()
-			c.sendToDLQ(ctx, m, "DB write failed")
-			continue
+	if err := c.svc.CreateOrder(ctx, &order); err != nil {
+		if errors.Is(err, service.ErrOrderAlreadyExists) {
+			slog.InfoContext(ctx, "Order already exists, skipping duplicate message")
+			metrics.Inc(ctx, metrics.KafkaMessagesProcessedTotal.WithLabelValues("duplicate"))
+			return
 		}
 
-		log.Printf("Order %s saved successfully", order.OrderUID)
-		metrics.KafkaMessagesProcessedTotal.Inc()
+		slog.ErrorContext(ctx, "Failed to save order", "error", err)
+		span.RecordError(err)
+		c.sendToDLQ(ctx, m, reasonDBWrite, "DB write failed")
+		return
 	}
-}
 
-func (c *Consumer) sendToDLQ(ctx context.Context, msg kafka.Message, reason string) {
-	payload := map[string]interface{}{
-		"original_key":   string(msg.Key),
-		"original_value": string(msg.Value),
-		"reason":         reason,
-		"time":           time.Now(),
+	slog.InfoContext(ctx, "Order saved", logging.Duration(time.Since(start)))
+	metrics.Inc(ctx, metrics.KafkaMessagesProcessedTotal.WithLabelValues("saved"))
+	if !m.Time.IsZero() {
+		metrics.Observe(ctx, metrics.KafkaEndToEndLatency, time.Since(m.Time).Seconds())
 	}
-	data, err := json.Marshal(payload)
+}
+
+// sendToDLQ counts a message rejected for reason, marks its processing span
+// as failed and forwards it to the DLQ with a human-readable detail. PII in
+// detail is masked; the original message and its content type are kept as
+// is so it can be replayed, see DLQ.Replay.
+func (c *Consumer) sendToDLQ(ctx context.Context, msg kafka.Message, reason, detail string) {
+	detail = pii.Scrub(detail)
+	metrics.Inc(ctx, metrics.KafkaProcessingErrorsTotal.WithLabelValues(reason))
+	trace.SpanFromContext(ctx).SetStatus(codes.Error, detail)
+
+	data, err := json.Marshal(newDLQPayload(msg, detail))
 	if err != nil {
-		log.Printf("Failed to marshal DLQ payload: %v", err)
+		slog.ErrorContext(ctx, "Failed to marshal DLQ payload", "error", err)
 		return
 	}
 
-	if err := c.dlqWriter.WriteMessages(ctx, kafka.Message{
+	dlqMsg := kafka.Message{
 		Key:   msg.Key,
 		Value: data,
 		Time:  time.Now(),
-	}); err != nil {
-		log.Printf("Failed to send to DLQ: %v", err)
+	}
+	if ct := headerValue(msg, HeaderContentType); ct != "" {
+		dlqMsg.Headers = append(dlqMsg.Headers, kafka.Header{Key: HeaderContentType, Value: []byte(ct)})
+	}
+	injectTraceContext(ctx, &dlqMsg)
+	if err := c.dlqWriter.WriteMessages(ctx, dlqMsg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send to DLQ", "error", err)
 	} else {
-		log.Printf("Message sent to DLQ (key=%s): %s", msg.Key, reason)
-		metrics.KafkaDLQMessagesTotal.Inc()
+		slog.InfoContext(ctx, "Message sent to DLQ", "key", string(msg.Key), "reason", reason)
+		metrics.Inc(ctx, metrics.KafkaDLQMessagesTotal.WithLabelValues(reason))
 	}
 }
 
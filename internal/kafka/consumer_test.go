@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestConsumer_Check(t *testing.T) {
+	c := &Consumer{}
+	check := c.Check(time.Minute)
+
+	if err := check(context.Background()); err == nil {
+		t.Error("a consumer that is not running should not be ready")
+	}
+
+	c.running.Store(true)
+	c.beat()
+	if err := check(context.Background()); err == nil {
+		t.Error("a consumer that has not connected to Kafka yet should not be ready")
+	}
+
+	c.ready.Store(true)
+	if err := check(context.Background()); err != nil {
+		t.Errorf("running consumer: %v", err)
+	}
+
+	c.heartbeat.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	if err := check(context.Background()); err == nil {
+		t.Error("a consumer without progress for longer than stallAfter should not be ready")
+	}
+}
+
+// idleReader is a reader of a topic with nothing to read: every read times
+// out, after fetching if the broker is reachable.
+type idleReader struct {
+	reachable bool
+}
+
+func (r *idleReader) ReadMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case <-time.After(10 * time.Millisecond):
+		return kafka.Message{}, context.DeadlineExceeded
+	}
+}
+
+func (r *idleReader) Stats() kafka.ReaderStats {
+	if r.reachable {
+		return kafka.ReaderStats{Fetches: 1}
+	}
+	return kafka.ReaderStats{Errors: 1}
+}
+
+func (r *idleReader) Config() kafka.ReaderConfig { return kafka.ReaderConfig{Topic: "orders"} }
+func (r *idleReader) Close() error               { return nil }
+
+// consume runs c until the returned function is called, which waits for
+// Consume to return.
+func consume(t *testing.T, c *Consumer) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = c.Consume(ctx)
+	}()
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Consume did not return after cancellation")
+		}
+	}
+}
+
+func TestConsumer_IdleTopicIsReady(t *testing.T) {
+	c := &Consumer{reader: &idleReader{reachable: true}}
+	stop := consume(t, c)
+	defer stop()
+
+	check := c.Check(time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for check(context.Background()) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("a consumer of an idle topic should become ready: %v", check(context.Background()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConsumer_UnreachableIsNotReady(t *testing.T) {
+	c := &Consumer{reader: &idleReader{}}
+	stop := consume(t, c)
+
+	time.Sleep(100 * time.Millisecond)
+	if err := c.Check(time.Minute)(context.Background()); err == nil {
+		t.Error("a consumer that cannot fetch should not be ready")
+	}
+	// Consume waits between failed reads; stop fails the test unless it
+	// returns promptly regardless.
+	stop()
+}
@@ -0,0 +1,304 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/metrics"
+	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/pii"
+)
+
+// ActionReplay is the audit log action of a DLQ replay.
+const ActionReplay = "dlq.replay"
+
+// previewBytes is how much of an original value DLQMessage.Preview shows.
+const previewBytes = 512
+
+// dlqPayload is the value of a DLQ message. Values that are not valid
+// UTF-8, such as Avro and Protobuf, are kept in OriginalValueBase64 so that
+// they survive the JSON encoding unchanged.
+type dlqPayload struct {
+	OriginalKey         string    `json:"original_key"`
+	OriginalValue       string    `json:"original_value,omitempty"`
+	OriginalValueBase64 []byte    `json:"original_value_base64,omitempty"`
+	Reason              string    `json:"reason"`
+	Time                time.Time `json:"time"`
+}
+
+func newDLQPayload(msg kafka.Message, detail string) dlqPayload {
+	p := dlqPayload{
+		OriginalKey: string(msg.Key),
+		Reason:      detail,
+		Time:        time.Now(),
+	}
+	if utf8.Valid(msg.Value) {
+		p.OriginalValue = string(msg.Value)
+	} else {
+		p.OriginalValueBase64 = msg.Value
+	}
+	return p
+}
+
+func (p dlqPayload) value() []byte {
+	if p.OriginalValueBase64 != nil {
+		return p.OriginalValueBase64
+	}
+	return []byte(p.OriginalValue)
+}
+
+// DLQMessage is a rejected message waiting in the DLQ topic.
+type DLQMessage struct {
+	Partition   int       `json:"partition"`
+	Offset      int64     `json:"offset"`
+	Time        time.Time `json:"time"`
+	Key         string    `json:"key"`
+	ContentType string    `json:"content_type,omitempty"`
+	Reason      string    `json:"reason"`
+	// Preview is the start of the original value with delivery PII
+	// redacted, or its size if it is binary.
+	Preview string `json:"preview"`
+}
+
+// ReplayResult counts the messages a replay took off the DLQ.
+type ReplayResult struct {
+	// Replayed messages were sent back to the orders topic.
+	Replayed int `json:"replayed"`
+	// Skipped messages could not be decoded and were dropped.
+	Skipped int `json:"skipped"`
+}
+
+// DLQ inspects the DLQ topic and replays its messages to the orders topic.
+// It reads the DLQ as its own consumer group, so replayed messages are not
+// shown or replayed again, while peeking does not move the group forward.
+type DLQ struct {
+	brokers  []string
+	topic    string
+	dlqTopic string
+	groupID  string
+	sec      Security
+	recorder audit.Recorder
+
+	// firstWait is how long to wait for the first message, which includes
+	// joining the group; wait is how long to wait for each further one
+	// before the DLQ is taken to be drained.
+	firstWait time.Duration
+	wait      time.Duration
+
+	// mu serializes operations: a second reader in the group would make
+	// both rebalance.
+	mu sync.Mutex
+}
+
+// NewDLQ reads dlqTopic as groupID with a -dlq suffix and replays messages
+// to topic. Both connect to brokers with sec.
+func NewDLQ(brokers []string, topic, dlqTopic, groupID string, sec Security) *DLQ {
+	return &DLQ{
+		brokers:   brokers,
+		topic:     topic,
+		dlqTopic:  dlqTopic,
+		groupID:   groupID + "-dlq",
+		sec:       sec,
+		firstWait: 15 * time.Second,
+		wait:      2 * time.Second,
+	}
+}
+
+// AuditTo records every replay in rec, attributed to the actor in the
+// context passed to Replay.
+func (d *DLQ) AuditTo(rec audit.Recorder) {
+	d.recorder = rec
+}
+
+// Peek returns up to limit messages that are waiting to be replayed,
+// oldest first per partition.
+func (d *DLQ) Peek(ctx context.Context, limit int) ([]DLQMessage, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r := d.reader()
+	defer r.Close()
+
+	msgs, err := d.fetch(ctx, r, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]DLQMessage, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, inspect(m))
+	}
+	return out, nil
+}
+
+// Replay sends up to limit DLQ messages back to the orders topic with
+// their original key, value and content type, and commits them in the DLQ
+// group. Messages whose DLQ payload cannot be decoded are dropped.
+func (d *DLQ) Replay(ctx context.Context, limit int) (ReplayResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var res ReplayResult
+	r := d.reader()
+	defer r.Close()
+
+	msgs, err := d.fetch(ctx, r, limit)
+	if err != nil || len(msgs) == 0 {
+		return res, err
+	}
+
+	var out []kafka.Message
+	for _, m := range msgs {
+		orig, err := original(m)
+		if err != nil {
+			slog.WarnContext(ctx, "Dropping undecodable DLQ message",
+				"partition", m.Partition, "offset", m.Offset, "error", err)
+			res.Skipped++
+			continue
+		}
+		out = append(out, orig)
+	}
+
+	if len(out) > 0 {
+		w := kafka.NewWriter(kafka.WriterConfig{
+			Brokers:  d.brokers,
+			Topic:    d.topic,
+			Balancer: &kafka.Hash{},
+			Dialer:   d.sec.dialer(),
+		})
+		err := w.WriteMessages(ctx, out...)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return res, fmt.Errorf("write to %s: %w", d.topic, err)
+		}
+	}
+	res.Replayed = len(out)
+
+	if err := r.CommitMessages(ctx, msgs...); err != nil {
+		// The messages are replayed again next time; the consumer skips
+		// the orders already stored as duplicates.
+		return res, fmt.Errorf("commit replayed messages: %w", err)
+	}
+	metrics.KafkaDLQReplayedTotal.Add(float64(res.Replayed))
+	slog.InfoContext(ctx, "Replayed DLQ messages", "replayed", res.Replayed, "skipped", res.Skipped)
+
+	if d.recorder != nil {
+		entry := models.AuditEntry{
+			Action: ActionReplay,
+			Diff: map[string]models.FieldChange{
+				"replayed": {After: res.Replayed},
+				"skipped":  {After: res.Skipped},
+			},
+		}
+		if err := d.recorder.Record(ctx, entry); err != nil {
+			slog.ErrorContext(ctx, "Failed to audit DLQ replay", "error", err)
+		}
+	}
+	return res, nil
+}
+
+func (d *DLQ) reader() *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     d.brokers,
+		Topic:       d.dlqTopic,
+		GroupID:     d.groupID,
+		Dialer:      d.sec.dialer(),
+		StartOffset: kafka.FirstOffset,
+		MaxWait:     time.Second,
+	})
+}
+
+// fetch reads up to limit messages without committing them. It stops early
+// when no message arrives in time.
+func (d *DLQ) fetch(ctx context.Context, r *kafka.Reader, limit int) ([]kafka.Message, error) {
+	var msgs []kafka.Message
+	wait := d.firstWait
+	for len(msgs) < limit {
+		readCtx, cancel := context.WithTimeout(ctx, wait)
+		m, err := r.FetchMessage(readCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+			return nil, fmt.Errorf("read %s: %w", d.dlqTopic, err)
+		}
+		msgs = append(msgs, m)
+		wait = d.wait
+	}
+	return msgs, nil
+}
+
+// inspect describes a DLQ message without exposing the PII it carries.
+func inspect(m kafka.Message) DLQMessage {
+	out := DLQMessage{
+		Partition:   m.Partition,
+		Offset:      m.Offset,
+		Time:        m.Time,
+		Key:         string(m.Key),
+		ContentType: headerValue(m, HeaderContentType),
+	}
+
+	var p dlqPayload
+	if err := json.Unmarshal(m.Value, &p); err != nil {
+		out.Reason = "undecodable DLQ message"
+		out.Preview = fmt.Sprintf("%d bytes", len(m.Value))
+		return out
+	}
+	out.Reason = p.Reason
+	if p.OriginalValueBase64 != nil {
+		out.Preview = fmt.Sprintf("%d bytes", len(p.OriginalValueBase64))
+		return out
+	}
+
+	preview := redactedPreview(p.OriginalValue)
+	if len(preview) > previewBytes {
+		cut := previewBytes
+		for cut > 0 && !utf8.RuneStart(preview[cut]) {
+			cut--
+		}
+		preview = preview[:cut] + "..."
+	}
+	out.Preview = preview
+	return out
+}
+
+// redactedPreview re-encodes a JSON order with its delivery PII redacted.
+// Anything else only has emails and phone numbers scrubbed, as names and
+// addresses cannot be told apart from other text.
+func redactedPreview(value string) string {
+	var order models.Order
+	if err := json.Unmarshal([]byte(value), &order); err == nil {
+		if data, err := json.Marshal(pii.RedactOrder(&order)); err == nil {
+			return string(data)
+		}
+	}
+	return pii.Scrub(value)
+}
+
+// original rebuilds the message that was sent to the DLQ.
+func original(m kafka.Message) (kafka.Message, error) {
+	var p dlqPayload
+	if err := json.Unmarshal(m.Value, &p); err != nil {
+		return kafka.Message{}, err
+	}
+
+	orig := kafka.Message{
+		Key:   []byte(p.OriginalKey),
+		Value: p.value(),
+	}
+	if ct := headerValue(m, HeaderContentType); ct != "" {
+		orig.Headers = []kafka.Header{{Key: HeaderContentType, Value: []byte(ct)}}
+	}
+	return orig, nil
+}
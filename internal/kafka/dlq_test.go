@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// dlqMessage builds the DLQ message the consumer would send for orig.
+func dlqMessage(t *testing.T, orig kafka.Message) kafka.Message {
+	t.Helper()
+	data, err := json.Marshal(newDLQPayload(orig, "validation failed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := kafka.Message{Key: orig.Key, Value: data}
+	if ct := headerValue(orig, HeaderContentType); ct != "" {
+		m.Headers = []kafka.Header{{Key: HeaderContentType, Value: []byte(ct)}}
+	}
+	return m
+}
+
+func TestDLQ_OriginalRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+		ct    string
+	}{
+		{"json", []byte(`{"order_uid":"b563feb7b2b84b6test"}`), ContentTypeJSON},
+		{"binary", []byte{0, 0, 0, 0, 7, 0xff, 0xfe, 0x80}, ContentTypeAvro},
+		{"no content type", []byte("not an order"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := kafka.Message{Key: []byte("b563feb7b2b84b6test"), Value: tt.value}
+			if tt.ct != "" {
+				orig.Headers = []kafka.Header{{Key: HeaderContentType, Value: []byte(tt.ct)}}
+			}
+
+			got, err := original(dlqMessage(t, orig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got.Key) != string(orig.Key) || !bytes.Equal(got.Value, orig.Value) {
+				t.Errorf("original = %q/%v, want %q/%v", got.Key, got.Value, orig.Key, orig.Value)
+			}
+			if ct := headerValue(got, HeaderContentType); ct != tt.ct {
+				t.Errorf("content type = %q, want %q", ct, tt.ct)
+			}
+		})
+	}
+}
+
+func TestDLQ_OriginalUndecodable(t *testing.T) {
+	if _, err := original(kafka.Message{Value: []byte("garbage")}); err == nil {
+		t.Error("expected an error for a value that is not a DLQ payload")
+	}
+}
+
+func TestDLQ_InspectRedactsPII(t *testing.T) {
+	order := testOrder()
+	value, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := inspect(dlqMessage(t, kafka.Message{Key: []byte(order.OrderUID), Value: value}))
+	if msg.Reason != "validation failed" || msg.Key != order.OrderUID {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	for _, v := range []string{order.Delivery.Name, order.Delivery.Phone, order.Delivery.Email, order.Delivery.Address} {
+		if strings.Contains(msg.Preview, v) {
+			t.Errorf("preview leaks %q: %s", v, msg.Preview)
+		}
+	}
+	if !strings.Contains(msg.Preview, order.TrackNumber) {
+		t.Errorf("preview lacks the order data: %s", msg.Preview)
+	}
+
+	// Text that is not an order still has emails and phones scrubbed.
+	msg = inspect(dlqMessage(t, kafka.Message{Value: []byte("call +79161234567 " + strings.Repeat("x", 1000))}))
+	if strings.Contains(msg.Preview, "+79161234567") {
+		t.Errorf("preview leaks the phone: %s", msg.Preview)
+	}
+	if len(msg.Preview) > previewBytes+len("...") {
+		t.Errorf("preview is %d bytes, want at most %d", len(msg.Preview), previewBytes)
+	}
+
+	msg = inspect(dlqMessage(t, kafka.Message{Value: []byte{0xff, 0xfe}}))
+	if msg.Preview != "2 bytes" {
+		t.Errorf("binary preview = %q", msg.Preview)
+	}
+}
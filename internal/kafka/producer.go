@@ -2,27 +2,46 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/sonni-a/wb-service/internal/logging"
 	"github.com/sonni-a/wb-service/internal/models"
+	"go.opentelemetry.io/otel/codes"
 )
 
-func SendOrder(ctx context.Context, brokers []string, topic string, order *models.Order) error {
+func SendOrder(ctx context.Context, brokers []string, sec Security, topic string, order *models.Order) error {
+	return SendOrderWithCodec(ctx, brokers, sec, topic, JSONCodec{}, order)
+}
+
+// SendOrderWithCodec encodes the order with codec and tags the message with
+// the codec's content type so the consumer can pick the matching decoder.
+// The message also carries the trace context of the publish span.
+func SendOrderWithCodec(ctx context.Context, brokers []string, sec Security, topic string, codec Codec, order *models.Order) (err error) {
+	ctx = logging.With(ctx, logging.KeyTopic, topic, logging.KeyOrderUID, order.OrderUID)
+	ctx, span := startProducerSpan(ctx, topic)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	w := kafka.NewWriter(kafka.WriterConfig{
 		Brokers:  brokers,
 		Topic:    topic,
 		Balancer: &kafka.LeastBytes{},
+		Dialer:   sec.dialer(),
 	})
 	defer func() {
 		if err := w.Close(); err != nil {
-			log.Println("kafka producer close error:", err)
+			slog.ErrorContext(ctx, "Kafka producer close error", "error", err)
 		}
 	}()
 
-	data, err := json.Marshal(order)
+	data, err := codec.Encode(ctx, topic, order)
 	if err != nil {
 		return err
 	}
@@ -30,16 +49,20 @@ func SendOrder(ctx context.Context, brokers []string, topic string, order *model
 	msg := kafka.Message{
 		Key:   []byte(order.OrderUID),
 		Value: data,
-		Time:  time.Now(),
+		Headers: []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(codec.ContentType())},
+		},
+		Time: time.Now(),
 	}
+	injectTraceContext(ctx, &msg)
 
 	for i := 0; i < 5; i++ {
-		if err := w.WriteMessages(ctx, msg); err != nil {
-			log.Printf("Kafka write error, retry %d/5: %v", i+1, err)
+		if err = w.WriteMessages(ctx, msg); err != nil {
+			slog.WarnContext(ctx, "Kafka write error, retrying", "attempt", i+1, "max_attempts", 5, "error", err)
 			time.Sleep(5 * time.Second)
 			continue
 		}
-		log.Printf("[Kafka Producer] Sent order %s", order.OrderUID)
+		slog.InfoContext(ctx, "Order sent")
 		return nil
 	}
 
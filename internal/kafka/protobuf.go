@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/sonni-a/wb-service/internal/models"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ProtobufCodec handles Confluent wire-format Protobuf. Schemas are compiled
+// at runtime from the .proto source stored in the registry, so messages are
+// decoded dynamically without generated Go types. Schema references to other
+// subjects are not supported.
+type ProtobufCodec struct {
+	registry SchemaRegistry
+	schema   string
+
+	mu    sync.Mutex
+	files map[int]protoreflect.FileDescriptor
+}
+
+var _ Codec = (*ProtobufCodec)(nil)
+
+func NewProtobufCodec(registry SchemaRegistry) *ProtobufCodec {
+	schema, err := schemaFS.ReadFile("schemas/order.proto")
+	if err != nil {
+		panic("failed to load embedded Protobuf schema: " + err.Error())
+	}
+
+	return &ProtobufCodec{
+		registry: registry,
+		schema:   string(schema),
+		files:    make(map[int]protoreflect.FileDescriptor),
+	}
+}
+
+func (c *ProtobufCodec) Name() string        { return "Protobuf" }
+func (c *ProtobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+func (c *ProtobufCodec) Encode(ctx context.Context, topic string, order *models.Order) ([]byte, error) {
+	id, err := c.registry.Register(ctx, subjectForTopic(topic), Schema{Type: SchemaTypeProtobuf, Schema: c.schema})
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := c.fileFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := json.Marshal(order)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamicpb.NewMessage(fd.Messages().Get(0))
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(text, msg); err != nil {
+		return nil, fmt.Errorf("convert order to Protobuf: %w", err)
+	}
+
+	// A single zero byte is the short form of message indexes [0],
+	// i.e. the first message in the schema.
+	buf := append(appendWireHeader(nil, id), 0)
+	return proto.MarshalOptions{}.MarshalAppend(buf, msg)
+}
+
+func (c *ProtobufCodec) Decode(ctx context.Context, data []byte, order *models.Order) error {
+	id, payload, err := parseWireHeader(data)
+	if err != nil {
+		return err
+	}
+
+	indexes, payload, err := parseMessageIndexes(payload)
+	if err != nil {
+		return err
+	}
+
+	fd, err := c.fileFor(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	md, err := messageByIndexes(fd.Messages(), indexes)
+	if err != nil {
+		return fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return fmt.Errorf("decode Protobuf payload: %w", err)
+	}
+
+	// protojson renders 64-bit integers as strings, which encoding/json will
+	// not put into int64 fields, so go through a plain map instead.
+	text, err := json.Marshal(messageToMap(msg))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(text, order)
+}
+
+// fileFor returns the compiled schema id, fetching it from the registry
+// unless it is cached. As in AvroCodec.codecFor, the lock is not held during
+// the fetch.
+func (c *ProtobufCodec) fileFor(ctx context.Context, id int) (protoreflect.FileDescriptor, error) {
+	c.mu.Lock()
+	fd, ok := c.files[id]
+	c.mu.Unlock()
+	if ok {
+		return fd, nil
+	}
+
+	schema, err := c.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Type != SchemaTypeProtobuf {
+		return nil, fmt.Errorf("schema %d is %s, not Protobuf", id, schema.Type)
+	}
+
+	const name = "schema.proto"
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{name: schema.Schema}),
+		}),
+	}
+
+	files, err := compiler.Compile(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("compile Protobuf schema %d: %w", id, err)
+	}
+	if files[0].Messages().Len() == 0 {
+		return nil, fmt.Errorf("Protobuf schema %d has no messages", id)
+	}
+
+	c.mu.Lock()
+	c.files[id] = files[0]
+	c.mu.Unlock()
+	return files[0], nil
+}
+
+// parseMessageIndexes reads the zigzag-encoded message index path that
+// follows the schema ID in Confluent Protobuf messages.
+func parseMessageIndexes(data []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return nil, nil, errInvalidWireFormat
+	}
+	data = data[n:]
+
+	if count == 0 {
+		return []int{0}, data, nil
+	}
+	// Each index takes at least a byte; a larger count is corrupt and must
+	// not size the allocation below.
+	if count > int64(len(data)) {
+		return nil, nil, errInvalidWireFormat
+	}
+
+	indexes := make([]int, count)
+	for i := range indexes {
+		idx, n := binary.Varint(data)
+		if n <= 0 || idx < 0 {
+			return nil, nil, errInvalidWireFormat
+		}
+		indexes[i] = int(idx)
+		data = data[n:]
+	}
+	return indexes, data, nil
+}
+
+func messageByIndexes(msgs protoreflect.MessageDescriptors, indexes []int) (protoreflect.MessageDescriptor, error) {
+	var md protoreflect.MessageDescriptor
+	for _, idx := range indexes {
+		if idx >= msgs.Len() {
+			return nil, fmt.Errorf("message index %v out of range", indexes)
+		}
+		md = msgs.Get(idx)
+		msgs = md.Messages()
+	}
+	return md, nil
+}
+
+func messageToMap(msg protoreflect.Message) map[string]any {
+	out := make(map[string]any)
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsList() {
+			list := v.List()
+			items := make([]any, list.Len())
+			for i := range items {
+				items[i] = fieldValue(fd, list.Get(i))
+			}
+			out[string(fd.Name())] = items
+			return true
+		}
+		out[string(fd.Name())] = fieldValue(fd, v)
+		return true
+	})
+	return out
+}
+
+func fieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	if fd.Message() != nil {
+		return messageToMap(v.Message())
+	}
+	return v.Interface()
+}
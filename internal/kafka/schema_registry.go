@@ -0,0 +1,155 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+
+	registryContentType = "application/vnd.schemaregistry.v1+json"
+)
+
+type Schema struct {
+	Type   string
+	Schema string
+}
+
+// SchemaRegistry resolves schema IDs used in the Confluent wire format.
+type SchemaRegistry interface {
+	SchemaByID(ctx context.Context, id int) (Schema, error)
+	Register(ctx context.Context, subject string, schema Schema) (int, error)
+}
+
+// RegistryClient talks to a Confluent-compatible schema registry over its
+// REST API. Schemas are immutable once registered, so both lookups by ID and
+// registrations are cached for the lifetime of the client.
+type RegistryClient struct {
+	baseURL string
+	client  *http.Client
+
+	mu         sync.RWMutex
+	byID       map[int]Schema
+	registered map[string]int
+}
+
+var _ SchemaRegistry = (*RegistryClient)(nil)
+
+func NewRegistryClient(baseURL string) *RegistryClient {
+	return &RegistryClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		byID:       make(map[int]Schema),
+		registered: make(map[string]int),
+	}
+}
+
+type registrySchema struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+}
+
+func (c *RegistryClient) SchemaByID(ctx context.Context, id int) (Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var resp registrySchema
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &resp); err != nil {
+		return Schema{}, fmt.Errorf("get schema %d: %w", id, err)
+	}
+
+	schema = Schema{Type: resp.SchemaType, Schema: resp.Schema}
+	if schema.Type == "" {
+		// The registry omits schemaType for Avro, its default.
+		schema.Type = SchemaTypeAvro
+	}
+
+	c.mu.Lock()
+	c.byID[id] = schema
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+func (c *RegistryClient) Register(ctx context.Context, subject string, schema Schema) (int, error) {
+	key := subject + "\x00" + schema.Type + "\x00" + schema.Schema
+
+	c.mu.RLock()
+	id, ok := c.registered[key]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	req := registrySchema{Schema: schema.Schema}
+	if schema.Type != SchemaTypeAvro {
+		req.SchemaType = schema.Type
+	}
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := c.do(ctx, http.MethodPost, path, req, &resp); err != nil {
+		return 0, fmt.Errorf("register schema for %s: %w", subject, err)
+	}
+
+	c.mu.Lock()
+	c.registered[key] = resp.ID
+	c.byID[resp.ID] = schema
+	c.mu.Unlock()
+
+	return resp.ID, nil
+}
+
+func (c *RegistryClient) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", registryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", registryContentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("schema registry returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// subjectForTopic follows the registry's default TopicNameStrategy.
+func subjectForTopic(topic string) string {
+	return topic + "-value"
+}
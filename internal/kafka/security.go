@@ -0,0 +1,50 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanisms accepted by NewSASL.
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+// Security holds the TLS and SASL settings of broker connections. The zero
+// value connects in plaintext without authentication.
+type Security struct {
+	TLS  *tls.Config
+	SASL sasl.Mechanism
+}
+
+// NewSASL returns the SASL mechanism with the given name, or nil for an
+// empty name.
+func NewSASL(mechanism, username, password string) (sasl.Mechanism, error) {
+	switch mechanism {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, username, password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unknown SASL mechanism %q", mechanism)
+	}
+}
+
+// dialer is kafka.DefaultDialer with s applied.
+func (s Security) dialer() *kafka.Dialer {
+	d := *kafka.DefaultDialer
+	d.TLS = s.TLS
+	d.SASLMechanism = s.SASL
+	return &d
+}
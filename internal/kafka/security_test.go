@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sonni-a/wb-service/internal/tlsutil"
+	"github.com/sonni-a/wb-service/internal/tlsutil/tlstest"
+)
+
+func TestNewSASL(t *testing.T) {
+	for _, name := range []string{SASLPlain, SASLScramSHA256, SASLScramSHA512} {
+		m, err := NewSASL(name, "svc", "secret")
+		if err != nil || m == nil {
+			t.Errorf("NewSASL(%q) = %v, %v", name, m, err)
+			continue
+		}
+		if got := m.Name(); got != map[string]string{
+			SASLPlain:       "PLAIN",
+			SASLScramSHA256: "SCRAM-SHA-256",
+			SASLScramSHA512: "SCRAM-SHA-512",
+		}[name] {
+			t.Errorf("NewSASL(%q).Name() = %q", name, got)
+		}
+	}
+	if m, err := NewSASL("", "", ""); m != nil || err != nil {
+		t.Errorf("NewSASL(\"\") = %v, %v; want no mechanism", m, err)
+	}
+	if _, err := NewSASL("gssapi", "svc", "secret"); err == nil {
+		t.Error("unknown mechanism accepted")
+	}
+}
+
+// TestSecurity_DialerTLS dials a broker stand-in that requires a client
+// certificate, as brokers with ssl.client.auth=required do.
+func TestSecurity_DialerTLS(t *testing.T) {
+	ca := tlstest.NewCA(t, "kafka-ca")
+	brokerCert := ca.Server(t, "broker")
+	clientCert := ca.Client(t, "order-service")
+
+	certs, err := tlsutil.NewCertReloader(brokerCert.CertFile, brokerCert.KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs, err := tlsutil.LoadCertPool(ca.CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := tlsutil.ServerConfig(certs, clientCAs)
+	serverConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	peer := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			peer <- ""
+			return
+		}
+		defer conn.Close()
+		tc := conn.(*tls.Conn)
+		if err := tc.Handshake(); err != nil {
+			peer <- ""
+			return
+		}
+		peer <- tc.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}()
+
+	clientConfig, err := tlsutil.ClientConfig(ca.CertFile, clientCert.CertFile, clientCert.KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := Security{TLS: clientConfig}.dialer().DialContext(ctx, "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial over TLS: %v", err)
+	}
+	defer conn.Close()
+
+	if cn := <-peer; cn != "order-service" {
+		t.Errorf("broker saw client certificate %q, want order-service", cn)
+	}
+
+	if d := (Security{}).dialer(); d.TLS != nil || d.SASLMechanism != nil || d.Timeout != kafka.DefaultDialer.Timeout {
+		t.Errorf("zero Security dialer = %+v, want the default dialer", d)
+	}
+}
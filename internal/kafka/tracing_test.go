@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextPropagation(t *testing.T) {
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx, producer := startProducerSpan(context.Background(), "orders")
+	m := kafka.Message{
+		Topic:   "orders",
+		Headers: []kafka.Header{{Key: HeaderContentType, Value: []byte(ContentTypeJSON)}},
+	}
+	injectTraceContext(ctx, &m)
+	producer.End()
+
+	carrier := headerCarrier{&m.Headers}
+	if carrier.Get("traceparent") == "" {
+		t.Fatalf("traceparent header not set: %v", m.Headers)
+	}
+	if got := carrier.Get(HeaderContentType); got != ContentTypeJSON {
+		t.Errorf("content type header = %q", got)
+	}
+
+	_, consumer := startConsumerSpan(context.Background(), m)
+	consumer.End()
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	p, c := spans[0], spans[1]
+	if c.SpanKind() != trace.SpanKindConsumer || p.SpanKind() != trace.SpanKindProducer {
+		t.Errorf("span kinds = %v, %v", p.SpanKind(), c.SpanKind())
+	}
+	if c.Parent().TraceID() != p.SpanContext().TraceID() || c.Parent().SpanID() != p.SpanContext().SpanID() {
+		t.Error("consumer span is not a child of the producer span")
+	}
+}
+
+func TestHeaderCarrier_SetReplaces(t *testing.T) {
+	var headers []kafka.Header
+	c := headerCarrier{&headers}
+	c.Set("traceparent", "a")
+	c.Set("traceparent", "b")
+
+	if len(headers) != 1 || c.Get("traceparent") != "b" {
+		t.Errorf("headers = %v", headers)
+	}
+	if keys := c.Keys(); len(keys) != 1 || keys[0] != "traceparent" {
+		t.Errorf("Keys() = %v", keys)
+	}
+}
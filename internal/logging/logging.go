@@ -0,0 +1,149 @@
+// Package logging sets up the process-wide slog logger and carries log
+// fields in contexts. Once Setup has run, messages written with the
+// standard log package go through the same handler at info level. Email
+// addresses and phone numbers in messages and string or error fields are
+// masked before they are written.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/pii"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field names shared by all packages, so that log records about the same
+// order, message or request can be filtered on the same keys.
+const (
+	KeyOrderUID   = "order_uid"
+	KeyTopic      = "topic"
+	KeyPartition  = "partition"
+	KeyOffset     = "offset"
+	KeyRequestID  = "request_id"
+	KeyDurationMS = "duration_ms"
+	KeyTraceID    = "trace_id"
+	KeySpanID     = "span_id"
+)
+
+var level = new(slog.LevelVar)
+
+// Setup makes a logger writing to stderr at the given level the default.
+// format is json or text.
+func Setup(lvl, format string) error {
+	return setup(os.Stderr, lvl, format)
+}
+
+func setup(w io.Writer, lvl, format string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: scrub}
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q", format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{h}))
+	return nil
+}
+
+// scrub masks PII in string and error values, which covers the message and
+// errors wrapping invalid input.
+func scrub(_ []string, a slog.Attr) slog.Attr {
+	switch v := a.Value.Any().(type) {
+	case string:
+		a.Value = slog.StringValue(pii.Scrub(v))
+	case error:
+		a.Value = slog.StringValue(pii.Scrub(v.Error()))
+	}
+	return a
+}
+
+// SetLevel changes the minimum level of the default logger; it is safe to
+// call while other goroutines are logging.
+func SetLevel(lvl string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(lvl)); err != nil {
+		return fmt.Errorf("invalid log level %q", lvl)
+	}
+	level.Set(l)
+	return nil
+}
+
+func Level() slog.Level {
+	return level.Level()
+}
+
+// Fatal logs at error level and exits with status 1.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Duration is the duration_ms field.
+func Duration(d time.Duration) slog.Attr {
+	return slog.Float64(KeyDurationMS, float64(d.Microseconds())/1000)
+}
+
+type ctxKey struct{}
+
+// With returns a context whose log records carry args, in the key-value
+// form slog.Info takes, on top of the fields ctx already carries. The
+// fields show up on records logged with the *Context functions of slog.
+func With(ctx context.Context, args ...any) context.Context {
+	var r slog.Record
+	r.Add(args...)
+
+	attrs := slices.Clip(attrsFrom(ctx))
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, ctxKey{}, attrs)
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the fields attached with With, and the IDs of the
+// current trace span, to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := attrsFrom(ctx)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(slices.Clip(attrs),
+			slog.String(KeyTraceID, sc.TraceID().String()),
+			slog.String(KeySpanID, sc.SpanID().String()),
+		)
+	}
+	if len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func capture(t *testing.T, lvl string) *bytes.Buffer {
+	t.Helper()
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	var buf bytes.Buffer
+	if err := setup(&buf, lvl, "json"); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestWith_AddsContextFields(t *testing.T) {
+	buf := capture(t, "info")
+
+	ctx := With(context.Background(), KeyTopic, "orders", KeyPartition, 2)
+	child := With(ctx, KeyOrderUID, "b563feb7b2b84b6test")
+	slog.InfoContext(child, "Order saved", Duration(1500*time.Microsecond))
+	slog.InfoContext(ctx, "Other")
+
+	recs := records(t, buf)
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2", len(recs))
+	}
+	got := recs[0]
+	if got[KeyTopic] != "orders" || got[KeyPartition] != float64(2) || got[KeyOrderUID] != "b563feb7b2b84b6test" {
+		t.Errorf("missing context fields: %v", got)
+	}
+	if got[KeyDurationMS] != 1.5 {
+		t.Errorf("%s = %v, want 1.5", KeyDurationMS, got[KeyDurationMS])
+	}
+	if _, ok := recs[1][KeyOrderUID]; ok {
+		t.Errorf("child fields leaked into the parent context: %v", recs[1])
+	}
+}
+
+func TestContextHandler_TraceIDs(t *testing.T) {
+	buf := capture(t, "info")
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	slog.InfoContext(ctx, "traced")
+
+	rec := records(t, buf)[0]
+	if rec[KeyTraceID] != sc.TraceID().String() || rec[KeySpanID] != sc.SpanID().String() {
+		t.Errorf("missing trace fields: %v", rec)
+	}
+}
+
+func TestSetup_ScrubsPII(t *testing.T) {
+	buf := capture(t, "info")
+
+	err := fmt.Errorf("save order: duplicate email john@example.com")
+	slog.Info("Call +79161231234 back", "error", err, "contact", "jane@example.com", "count", 3)
+
+	out := buf.String()
+	for _, leaked := range []string{"john@example.com", "jane@example.com", "9161231234"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("log output leaks %q: %s", leaked, out)
+		}
+	}
+	rec := records(t, buf)[0]
+	if rec["msg"] != "Call +7*****1234 back" || rec["error"] != "save order: duplicate email j***@example.com" || rec["count"] != float64(3) {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	buf := capture(t, "warn")
+
+	slog.Info("hidden")
+	slog.Warn("shown")
+	if err := SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	slog.Debug("shown too")
+
+	if recs := records(t, buf); len(recs) != 2 {
+		t.Errorf("got %d records, want 2: %s", len(recs), buf)
+	}
+	if err := SetLevel("loud"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestSetup_InvalidFormat(t *testing.T) {
+	if err := setup(new(bytes.Buffer), "info", "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestMiddleware_RequestID(t *testing.T) {
+	buf := capture(t, "info")
+
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slog.InfoContext(r.Context(), "inside")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/order/1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got != "req-1" {
+		t.Errorf("response %s = %q, want req-1", RequestIDHeader, got)
+	}
+
+	recs := records(t, buf)
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2", len(recs))
+	}
+	if recs[0][KeyRequestID] != "req-1" {
+		t.Errorf("handler log request_id = %v", recs[0][KeyRequestID])
+	}
+	access := recs[1]
+	if access[KeyRequestID] != "req-1" || access["status"] != float64(http.StatusTeapot) || access["path"] != "/order/1" {
+		t.Errorf("unexpected access log: %v", access)
+	}
+
+	// Without a client ID one is generated.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/order/1", nil))
+	if len(rec.Header().Get(RequestIDHeader)) != 32 {
+		t.Errorf("generated request ID = %q", rec.Header().Get(RequestIDHeader))
+	}
+}
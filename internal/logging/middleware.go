@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds request IDs taken from clients.
+const maxRequestIDLen = 128
+
+// quietPaths are polled constantly, so their requests are logged at debug
+// level only.
+var quietPaths = map[string]bool{
+	"/ping":    true,
+	"/metrics": true,
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// Middleware attaches a request ID to the request context and the response,
+// reusing the client's X-Request-ID if it sent one, and logs every request
+// once it completes.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := With(r.Context(), KeyRequestID, id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		lvl := slog.LevelInfo
+		if quietPaths[r.URL.Path] {
+			lvl = slog.LevelDebug
+		}
+		slog.Log(ctx, lvl, "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			Duration(time.Since(start)),
+		)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush a streamed export.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Handler serves the registered metrics, in the OpenMetrics format if the
+// scraper asks for it so that exemplars are included.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// Observe records v on o. If ctx carries a sampled trace span, the trace ID
+// is attached as an exemplar, so a latency spike on a dashboard links to a
+// trace that shows it. Exemplars are only exposed in the OpenMetrics format.
+func Observe(ctx context.Context, o prometheus.Observer, v float64) {
+	if labels := exemplarLabels(ctx); labels != nil {
+		if eo, ok := o.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(v, labels)
+			return
+		}
+	}
+	o.Observe(v)
+}
+
+// Inc increments c, attaching the trace ID of ctx as an exemplar like
+// Observe does.
+func Inc(ctx context.Context, c prometheus.Counter) {
+	if labels := exemplarLabels(ctx); labels != nil {
+		if ea, ok := c.(prometheus.ExemplarAdder); ok {
+			ea.AddWithExemplar(1, labels)
+			return
+		}
+	}
+	c.Inc()
+}
+
+func exemplarLabels(ctx context.Context) prometheus.Labels {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{"trace_id": sc.TraceID().String()}
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func sampledContext() (context.Context, trace.SpanContext) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0xab},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc), sc
+}
+
+func TestObserve_AttachesTraceExemplar(t *testing.T) {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Buckets: []float64{1}})
+	ctx, sc := sampledContext()
+
+	Observe(ctx, h, 0.5)
+	Observe(context.Background(), h, 2)
+
+	var m dto.Metric
+	if err := h.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetHistogram().GetSampleCount(); got != 2 {
+		t.Errorf("sample count = %d, want 2", got)
+	}
+	ex := m.GetHistogram().GetBucket()[0].GetExemplar()
+	if ex == nil || ex.GetLabel()[0].GetValue() != sc.TraceID().String() {
+		t.Errorf("exemplar = %v, want trace_id %s", ex, sc.TraceID())
+	}
+}
+
+func TestInc_AttachesTraceExemplar(t *testing.T) {
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total"})
+	ctx, sc := sampledContext()
+
+	Inc(ctx, c)
+
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m.GetCounter().GetValue() != 1 {
+		t.Errorf("value = %v, want 1", m.GetCounter().GetValue())
+	}
+	if ex := m.GetCounter().GetExemplar(); ex == nil || ex.GetLabel()[0].GetValue() != sc.TraceID().String() {
+		t.Errorf("exemplar = %v", ex)
+	}
+}
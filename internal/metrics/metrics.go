@@ -20,24 +20,74 @@ var (
 		[]string{"method", "path"},
 	)
 
-	KafkaMessagesProcessedTotal = prometheus.NewCounter(
+	HttpResponseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 7),
+		},
+		[]string{"method", "path"},
+	)
+
+	HttpRequestsInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests currently being served",
+		},
+	)
+
+	HttpRateLimitedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_rate_limited_total",
+			Help: "HTTP requests rejected with 429, by whether the caller was identified by principal or IP",
+		},
+		[]string{"caller"},
+	)
+
+	KafkaMessagesProcessedTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "kafka_messages_processed_total",
-			Help: "Total processed Kafka messages",
+			Help: "Total processed Kafka messages by outcome: saved or duplicate",
 		},
+		[]string{"outcome"},
 	)
 
-	KafkaProcessingErrorsTotal = prometheus.NewCounter(
+	KafkaProcessingErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "kafka_processing_errors_total",
-			Help: "Total Kafka processing errors",
+			Help: "Total Kafka processing errors by reason",
 		},
+		[]string{"reason"},
 	)
 
-	KafkaDLQMessagesTotal = prometheus.NewCounter(
+	KafkaDLQMessagesTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "kafka_dlq_messages_total",
-			Help: "Total messages sent to DLQ",
+			Help: "Total messages sent to DLQ by reason",
+		},
+		[]string{"reason"},
+	)
+
+	KafkaDLQReplayedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "kafka_dlq_replayed_total",
+			Help: "Total DLQ messages replayed to the orders topic",
+		},
+	)
+
+	KafkaConsumerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_consumer_lag",
+			Help: "Messages between the consumer's position and the end of the partition at the last fetch",
+		},
+		[]string{"topic"},
+	)
+
+	KafkaEndToEndLatency = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "kafka_end_to_end_latency_seconds",
+			Help:    "Time from the Kafka message timestamp until the order is stored",
+			Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
 		},
 	)
 
@@ -55,6 +105,13 @@ var (
 		},
 	)
 
+	CacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cache_size",
+			Help: "Number of orders in the cache",
+		},
+	)
+
 	DBQueryDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "db_query_duration_seconds",
@@ -63,17 +120,62 @@ var (
 		},
 		[]string{"operation"},
 	)
+
+	DBReplicaHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "db_replica_healthy",
+			Help: "Whether a read replica is serving reads (1) or out of rotation (0)",
+		},
+		[]string{"replica"},
+	)
+
+	DBPools = NewPoolCollector()
+
+	HealthCheckUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_check_up",
+			Help: "Result of the last run of a liveness or readiness check: up (1) or down (0)",
+		},
+		[]string{"probe", "check"},
+	)
+
+	ConfigVersion = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_version",
+			Help: "Version of the active configuration, incremented by every reload that changes it",
+		},
+	)
+
+	ConfigReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "Configuration reload attempts",
+		},
+		[]string{"result"},
+	)
 )
 
 func Init() {
 	prometheus.MustRegister(
 		HttpRequestsTotal,
 		HttpRequestDuration,
+		HttpResponseSize,
+		HttpRequestsInFlight,
+		HttpRateLimitedTotal,
 		KafkaMessagesProcessedTotal,
 		KafkaProcessingErrorsTotal,
 		KafkaDLQMessagesTotal,
+		KafkaDLQReplayedTotal,
+		KafkaConsumerLag,
+		KafkaEndToEndLatency,
 		CacheHitsTotal,
 		CacheMissesTotal,
+		CacheSize,
 		DBQueryDuration,
+		DBReplicaHealthy,
+		DBPools,
+		HealthCheckUp,
+		ConfigVersion,
+		ConfigReloadsTotal,
 	)
 }
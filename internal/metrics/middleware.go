@@ -1,15 +1,21 @@
 package metrics
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// otherPath labels requests no route matched, so that scanners probing
+// random URLs do not create a label value per URL.
+const otherPath = "other"
+
 type statusRecorder struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int
 }
 
 func (r *statusRecorder) WriteHeader(code int) {
@@ -17,35 +23,74 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
-func normalizePath(path string) string {
-	if strings.HasPrefix(path, "/order/") {
-		return "/order/{id}"
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+type routeKey struct{}
+
+// Routes wraps the ServeMux the middleware chain ends in; next must hand
+// the request to the mux as is. The mux sets r.Pattern to the pattern it
+// matched while routing, but the middlewares only see their own copy of the
+// request, so Routes hands the pattern back to MetricsMiddleware.
+func Routes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+			*route = r.Pattern
+		}
+	})
+}
+
+// routeLabel is the path label for a ServeMux pattern: the pattern without
+// its method and {$} anchor, e.g. /order/{uid}, or other if nothing matched.
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return otherPath
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = path
 	}
-	return path
+	return strings.TrimSuffix(pattern, "{$}")
 }
 
+// MetricsMiddleware records request counts, latency and response sizes
+// labelled with the route from Routes, which must wrap the mux at the end
+// of next.
 func MetricsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		start := time.Now()
+		HttpRequestsInFlight.Inc()
+		defer HttpRequestsInFlight.Dec()
 
 		rec := &statusRecorder{
 			ResponseWriter: w,
 			statusCode:     http.StatusOK,
 		}
 
-		next.ServeHTTP(rec, r)
+		route := new(string)
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
 
 		duration := time.Since(start).Seconds()
 
-		path := normalizePath(r.URL.Path)
+		path := routeLabel(*route)
 
 		HttpRequestsTotal.
 			WithLabelValues(r.Method, path, strconv.Itoa(rec.statusCode)).
 			Inc()
 
-		HttpRequestDuration.
+		Observe(r.Context(), HttpRequestDuration.WithLabelValues(r.Method, path), duration)
+
+		HttpResponseSize.
 			WithLabelValues(r.Method, path).
-			Observe(duration)
+			Observe(float64(rec.bytes))
 	})
 }
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetricsMiddleware_LabelsByRoute(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /order/{uid}", func(w http.ResponseWriter, r *http.Request) {
+		if got := testutil.ToFloat64(HttpRequestsInFlight); got != 1 {
+			t.Errorf("in-flight = %v during the request, want 1", got)
+		}
+		_, _ = w.Write([]byte("hello"))
+	})
+	mux.Handle("/css/", http.NotFoundHandler())
+
+	// A middleware between MetricsMiddleware and the mux copies the request,
+	// as the logging and tracing middlewares do.
+	h := MetricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Routes(mux).ServeHTTP(w, r.WithContext(r.Context()))
+	}))
+
+	for _, target := range []string{"/order/a", "/order/b", "/wp-admin", "/.env", "/css/x.css"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	if got := testutil.ToFloat64(HttpRequestsTotal.WithLabelValues("GET", "/order/{uid}", "200")); got != 2 {
+		t.Errorf("/order/{uid} requests = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(HttpRequestsTotal.WithLabelValues("GET", otherPath, "404")); got != 2 {
+		t.Errorf("unmatched requests = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(HttpRequestsTotal.WithLabelValues("GET", "/css/", "404")); got != 1 {
+		t.Errorf("/css/ requests = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(HttpRequestsInFlight); got != 0 {
+		t.Errorf("in-flight = %v after the requests, want 0", got)
+	}
+
+	// Two GET /order/{uid} responses of 5 bytes each.
+	sizes := HttpResponseSize.WithLabelValues("GET", "/order/{uid}")
+	if sum := histogramSum(t, sizes); sum != 10 {
+		t.Errorf("response size sum = %v, want 10", sum)
+	}
+}
+
+func TestRouteLabel(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"":                 otherPath,
+		"GET /order/{uid}": "/order/{uid}",
+		"/css/":            "/css/",
+		"/{$}":             "/",
+	} {
+		if got := routeLabel(pattern); got != want {
+			t.Errorf("routeLabel(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func histogramSum(t *testing.T, o prometheus.Observer) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := o.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleSum()
+}
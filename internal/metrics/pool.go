@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector exports pgxpool statistics, read at scrape time, for every
+// pool added to it. Pools are labelled with the name given to Add.
+type PoolCollector struct {
+	mu    sync.Mutex
+	pools map[string]*pgxpool.Pool
+
+	acquired      *prometheus.Desc
+	idle          *prometheus.Desc
+	constructing  *prometheus.Desc
+	total         *prometheus.Desc
+	max           *prometheus.Desc
+	acquireCount  *prometheus.Desc
+	acquireWait   *prometheus.Desc
+	emptyAcquire  *prometheus.Desc
+	emptyWait     *prometheus.Desc
+	canceledCount *prometheus.Desc
+}
+
+func NewPoolCollector() *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("db_pool_"+name, help, []string{"pool"}, nil)
+	}
+
+	return &PoolCollector{
+		pools:         make(map[string]*pgxpool.Pool),
+		acquired:      desc("acquired_conns", "Connections currently checked out of the pool"),
+		idle:          desc("idle_conns", "Idle connections in the pool"),
+		constructing:  desc("constructing_conns", "Connections being established"),
+		total:         desc("total_conns", "All connections in the pool"),
+		max:           desc("max_conns", "Maximum size of the pool"),
+		acquireCount:  desc("acquire_total", "Successful connection acquires"),
+		acquireWait:   desc("acquire_duration_seconds_total", "Total time spent acquiring connections"),
+		emptyAcquire:  desc("empty_acquire_total", "Acquires that had to wait because the pool had no idle connection"),
+		emptyWait:     desc("empty_acquire_wait_seconds_total", "Total time acquires waited for a connection to become available"),
+		canceledCount: desc("canceled_acquire_total", "Acquires canceled by their context"),
+	}
+}
+
+func (c *PoolCollector) Add(name string, pool *pgxpool.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pools[name] = pool
+}
+
+func (c *PoolCollector) Remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pools, name)
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.acquired, c.idle, c.constructing, c.total, c.max,
+		c.acquireCount, c.acquireWait, c.emptyAcquire, c.emptyWait, c.canceledCount,
+	} {
+		ch <- d
+	}
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, pool := range c.pools {
+		s := pool.Stat()
+		gauge := func(d *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, name)
+		}
+		counter := func(d *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v, name)
+		}
+
+		gauge(c.acquired, float64(s.AcquiredConns()))
+		gauge(c.idle, float64(s.IdleConns()))
+		gauge(c.constructing, float64(s.ConstructingConns()))
+		gauge(c.total, float64(s.TotalConns()))
+		gauge(c.max, float64(s.MaxConns()))
+		counter(c.acquireCount, float64(s.AcquireCount()))
+		counter(c.acquireWait, s.AcquireDuration().Seconds())
+		counter(c.emptyAcquire, float64(s.EmptyAcquireCount()))
+		counter(c.emptyWait, s.EmptyAcquireWaitTime().Seconds())
+		counter(c.canceledCount, float64(s.CanceledAcquireCount()))
+	}
+}
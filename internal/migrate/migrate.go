@@ -0,0 +1,311 @@
+// Package migrate applies the embedded SQL migrations. Versions are tracked
+// in the schema_migrations table used by golang-migrate, so databases that
+// were migrated by the migrate/migrate container are picked up as is.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLockID serializes migrations between service replicas starting at
+// the same time.
+const advisoryLockID = 0x77625f6d69677261 // "wb_migra"
+
+const (
+	createVersionTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`
+	selectVersionQuery      = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	deleteVersionQuery      = `DELETE FROM schema_migrations`
+	insertVersionQuery      = `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`
+)
+
+var (
+	ErrDirty         = errors.New("database schema is dirty after a failed migration; fix it manually")
+	ErrSchemaTooOld  = errors.New("database schema is older than this binary expects")
+	ErrSchemaTooNew  = errors.New("database schema is newer than this binary expects")
+	ErrUnknownSchema = errors.New("database schema version is not one of the embedded migrations")
+)
+
+var fileNameRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads NNNNNN_name.up.sql / NNNNNN_name.down.sql pairs from fsys,
+// sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, e := range entries {
+		m := fileNameRegex.FindStringSubmatch(e.Name())
+		if m == nil || e.IsDir() {
+			continue
+		}
+
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.Up = string(data)
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Latest is the schema version this binary expects.
+func (m *Migrator) Latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the current schema version, 0 for an empty database.
+func (m *Migrator) Version(ctx context.Context) (uint64, bool, error) {
+	if _, err := m.pool.Exec(ctx, createVersionTableQuery); err != nil {
+		return 0, false, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return readVersion(ctx, m.pool)
+}
+
+// Check refuses schemas that are dirty, behind or ahead of the binary.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	return checkVersion(m.migrations, version, dirty)
+}
+
+// Up applies all pending migrations and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("version %d: %w", version, ErrDirty)
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version <= version {
+				continue
+			}
+			if err := apply(ctx, conn, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back up to steps migrations and returns how many were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("version %d: %w", version, ErrDirty)
+		}
+
+		for rolledBack < steps && version > 0 {
+			i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == version })
+			if i < 0 {
+				return fmt.Errorf("version %d: %w", version, ErrUnknownSchema)
+			}
+
+			mig := m.migrations[i]
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+			}
+
+			var prev uint64
+			if i > 0 {
+				prev = m.migrations[i-1].Version
+			}
+			if err := apply(ctx, conn, mig.Down, prev); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			version = prev
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+type Status struct {
+	Migration
+	Applied bool
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, uint64, bool, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		statuses[i] = Status{Migration: mig, Applied: mig.Version <= version}
+	}
+	return statuses, version, dirty, nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Migrations and waiting for another replica's migrations can take
+	// longer than the statement timeout configured for regular queries.
+	if _, err := conn.Exec(ctx, "SET statement_timeout = 0"); err != nil {
+		return fmt.Errorf("failed to disable statement timeout: %w", err)
+	}
+
+	// Advisory locks belong to the session, so lock, migrate and unlock on
+	// the same connection.
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		_, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+		if err == nil {
+			_, err = conn.Exec(context.Background(), "RESET statement_timeout")
+		}
+		if err != nil {
+			// Drop the connection rather than return it to the pool still
+			// locked or without a statement timeout.
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, createVersionTableQuery); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+// apply runs a script and records the resulting version in one transaction,
+// so a failed migration leaves the schema untouched rather than dirty.
+func apply(ctx context.Context, conn *pgxpool.Conn, script string, version uint64) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, deleteVersionQuery); err != nil {
+			return err
+		}
+		if version == 0 {
+			return nil
+		}
+		_, err := tx.Exec(ctx, insertVersionQuery, int64(version))
+		return err
+	})
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func readVersion(ctx context.Context, q querier) (uint64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := q.QueryRow(ctx, selectVersionQuery).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	// golang-migrate stores -1 for "no version".
+	if version < 0 {
+		return 0, dirty, nil
+	}
+	return uint64(version), dirty, nil
+}
+
+func checkVersion(migrations []Migration, version uint64, dirty bool) error {
+	var latest uint64
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("version %d: %w", version, ErrDirty)
+	case version > latest:
+		return fmt.Errorf("version %d, expected %d: %w", version, latest, ErrSchemaTooNew)
+	case version < latest:
+		return fmt.Errorf("version %d, expected %d: %w", version, latest, ErrSchemaTooOld)
+	}
+	return nil
+}
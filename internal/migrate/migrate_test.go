@@ -0,0 +1,107 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sonni-a/wb-service/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000010_add_index.up.sql":     {Data: []byte("CREATE INDEX i ON t(c);")},
+		"000010_add_index.down.sql":   {Data: []byte("DROP INDEX i;")},
+		"000002_create_t.up.sql":      {Data: []byte("CREATE TABLE t (c int);")},
+		"000002_create_t.down.sql":    {Data: []byte("DROP TABLE t;")},
+		"000003_no_down.up.sql":       {Data: []byte("SELECT 1;")},
+		"README.md":                   {Data: []byte("not a migration")},
+		"migrations.go":               {Data: []byte("package migrations")},
+		"000004_broken.sql":           {Data: []byte("ignored")},
+		"000005_create_x.down.sql.bk": {Data: []byte("ignored")},
+	}
+
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 2, Name: "create_t", Up: "CREATE TABLE t (c int);", Down: "DROP TABLE t;"},
+		{Version: 3, Name: "no_down", Up: "SELECT 1;"},
+		{Version: 10, Name: "add_index", Up: "CREATE INDEX i ON t(c);", Down: "DROP INDEX i;"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d migrations, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("migration %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing up": {
+			"000001_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		},
+		"name mismatch": {
+			"000001_a.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+			"000001_b.down.sql": {Data: []byte("DROP TABLE a;")},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(fsys); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) == 0 {
+		t.Fatalf("no embedded migrations")
+	}
+
+	for i, mig := range got {
+		if mig.Version != uint64(i+1) {
+			t.Errorf("expected contiguous versions, got %d at position %d", mig.Version, i)
+		}
+		if mig.Down == "" {
+			t.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	migs := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+
+	tests := []struct {
+		name    string
+		version uint64
+		dirty   bool
+		want    error
+	}{
+		{"up to date", 3, false, nil},
+		{"empty database", 0, false, ErrSchemaTooOld},
+		{"behind", 2, false, ErrSchemaTooOld},
+		{"ahead", 4, false, ErrSchemaTooNew},
+		{"dirty", 3, true, ErrDirty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVersion(migs, tt.version, tt.dirty)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// AuditEntry records a change to stored data: who made it, through which
+// source and what changed.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Actor     string    `json:"actor"`
+	Source    string    `json:"source"`
+	Action    string    `json:"action"`
+	OrderUID  *string   `json:"order_uid" extensions:"x-nullable"`
+	// Diff maps changed fields to their values before and after the
+	// change, see FieldChange.
+	Diff map[string]FieldChange `json:"diff,omitempty"`
+}
+
+// FieldChange is the change of one field. A nil side means the value did
+// not exist or is not recorded, as for erased personal data.
+type FieldChange struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
@@ -9,7 +9,7 @@ type Order struct {
 	TrackNumber       string    `json:"track_number"`
 	Entry             string    `json:"entry"`
 	Locale            string    `json:"locale"`
-	InternalSignature *string   `json:"internal_signature"`
+	InternalSignature *string   `json:"internal_signature" extensions:"x-nullable"`
 	CustomerID        string    `json:"customer_id"`
 	DeliveryService   string    `json:"delivery_service"`
 	ShardKey          string    `json:"shardkey"`
@@ -36,7 +36,7 @@ type Delivery struct {
 type Payment struct {
 	OrderUID     string  `json:"order_uid"`
 	Transaction  string  `json:"transaction"`
-	RequestID    *string `json:"request_id"`
+	RequestID    *string `json:"request_id" extensions:"x-nullable"`
 	Currency     string  `json:"currency"`
 	Provider     string  `json:"provider"`
 	Amount       int     `json:"amount"`
@@ -0,0 +1,323 @@
+// Package partition maintains the monthly date_created partitions of the
+// orders and items tables: it creates partitions ahead of time and retires
+// partitions that fall out of the retention window.
+package partition
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLockID makes sure only one replica runs maintenance at a time.
+const advisoryLockID = 0x77625f7061727469 // "wb_parti"
+
+const ArchiveSchema = "archive"
+
+const (
+	listPartitionsQuery = `
+SELECT c.relname
+FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = 'orders'::regclass`
+
+	foreignKeysQuery = `
+SELECT conname FROM pg_constraint WHERE conrelid = $1::text::regclass AND contype = 'f'`
+)
+
+var partitionNameRegex = regexp.MustCompile(`^orders_(\d{4})_(\d{2})$`)
+
+type Config struct {
+	// Premake is how many months after the current one get a partition.
+	Premake int
+	// Retention is how many full months before the current one are kept;
+	// 0 keeps everything.
+	Retention int
+	// Archive moves retired partitions to the archive schema instead of
+	// dropping them.
+	Archive bool
+}
+
+type Manager struct {
+	pool *pgxpool.Pool
+	cfg  Config
+	now  func() time.Time
+
+	onRetire []func(before time.Time)
+}
+
+func NewManager(pool *pgxpool.Pool, cfg Config) *Manager {
+	return &Manager{pool: pool, cfg: cfg, now: time.Now}
+}
+
+// OnRetire registers fn to be called with the retention cutoff after every
+// Run pass while retention is on: orders created before it are gone from
+// the orders table, or will be once the replica holding the maintenance
+// lock has retired them. It is meant for dropping such orders from caches,
+// so it is called on every replica and whether or not the pass failed.
+func (m *Manager) OnRetire(fn func(before time.Time)) {
+	m.onRetire = append(m.onRetire, fn)
+}
+
+// Run calls Maintain right away and then every interval until ctx is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Maintain(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Partition maintenance failed", "error", err)
+		}
+		m.notifyRetire()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) notifyRetire() {
+	if m.cfg.Retention == 0 {
+		return
+	}
+	before := retentionCutoff(m.now(), m.cfg)
+	for _, fn := range m.onRetire {
+		fn(before)
+	}
+}
+
+// Maintain brings the set of partitions in line with the config. It is a
+// no-op if another replica is already running it.
+func (m *Manager) Maintain(ctx context.Context) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", advisoryLockID).Scan(&locked); err != nil {
+		return fmt.Errorf("take maintenance lock: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+	defer func() {
+		_, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+		if err == nil {
+			_, err = conn.Exec(context.Background(), "RESET statement_timeout")
+		}
+		if err != nil {
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+
+	// Moving rows between partitions is not bound by the statement timeout
+	// meant for regular queries.
+	if _, err := conn.Exec(ctx, "SET statement_timeout = 0"); err != nil {
+		return fmt.Errorf("disable statement timeout: %w", err)
+	}
+
+	existing, err := listPartitions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	create, retire := plan(m.now(), existing, m.cfg)
+
+	for _, month := range create {
+		if err := createPartitions(ctx, conn, month); err != nil {
+			return fmt.Errorf("create partitions for %s: %w", month.Format("2006-01"), err)
+		}
+		slog.InfoContext(ctx, "Created partitions", "month", month.Format("2006-01"))
+	}
+
+	for _, month := range retire {
+		if err := retirePartitions(ctx, conn, month, m.cfg.Archive); err != nil {
+			return fmt.Errorf("retire partitions for %s: %w", month.Format("2006-01"), err)
+		}
+		if m.cfg.Archive {
+			slog.InfoContext(ctx, "Archived partitions", "month", month.Format("2006-01"), "schema", ArchiveSchema)
+		} else {
+			slog.InfoContext(ctx, "Dropped partitions", "month", month.Format("2006-01"))
+		}
+	}
+
+	return nil
+}
+
+// plan returns the months that need new partitions and the months whose
+// partitions are past retention, both in ascending order.
+func plan(now time.Time, existing []time.Time, cfg Config) (create, retire []time.Time) {
+	current := monthStart(now)
+
+	for i := 0; i <= cfg.Premake; i++ {
+		month := current.AddDate(0, i, 0)
+		if !slices.ContainsFunc(existing, month.Equal) {
+			create = append(create, month)
+		}
+	}
+
+	if cfg.Retention > 0 {
+		cutoff := retentionCutoff(now, cfg)
+		for _, month := range existing {
+			if month.Before(cutoff) {
+				retire = append(retire, month)
+			}
+		}
+		slices.SortFunc(retire, time.Time.Compare)
+	}
+
+	return create, retire
+}
+
+// retentionCutoff is the start of the oldest month retention keeps.
+func retentionCutoff(now time.Time, cfg Config) time.Time {
+	return monthStart(now).AddDate(0, -cfg.Retention, 0)
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func partitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_%04d_%02d", table, month.Year(), month.Month())
+}
+
+func parsePartitionName(name string) (time.Time, bool) {
+	m := partitionNameRegex.FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}, false
+	}
+	year, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	if month < 1 || month > 12 {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), true
+}
+
+func listPartitions(ctx context.Context, conn *pgxpool.Conn) ([]time.Time, error) {
+	rows, err := conn.Query(ctx, listPartitionsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("list partitions: %w", err)
+	}
+
+	var months []time.Time
+	for _, name := range names {
+		if month, ok := parsePartitionName(name); ok {
+			months = append(months, month)
+		}
+	}
+	return months, nil
+}
+
+// createPartitions adds the orders and items partitions for month. Rows that
+// already landed in the default partitions for that month are moved over,
+// since PostgreSQL refuses to attach a range the default partition covers.
+func createPartitions(ctx context.Context, conn *pgxpool.Conn, month time.Time) error {
+	from := month.Format(time.DateOnly)
+	to := month.AddDate(0, 1, 0).Format(time.DateOnly)
+	orders := pgx.Identifier{partitionName("orders", month)}.Sanitize()
+	items := pgx.Identifier{partitionName("items", month)}.Sanitize()
+	inRange := fmt.Sprintf("date_created >= '%s' AND date_created < '%s'", from, to)
+	bounds := fmt.Sprintf("FOR VALUES FROM ('%s') TO ('%s')", from, to)
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		return execAll(ctx, tx,
+			"CREATE TABLE "+orders+" (LIKE orders INCLUDING DEFAULTS INCLUDING CONSTRAINTS)",
+			"CREATE TABLE "+items+" (LIKE items INCLUDING DEFAULTS INCLUDING CONSTRAINTS)",
+			"INSERT INTO "+orders+" SELECT * FROM orders_default WHERE "+inRange,
+			"INSERT INTO "+items+" SELECT * FROM items_default WHERE "+inRange,
+			"DELETE FROM items_default WHERE "+inRange,
+			"DELETE FROM orders_default WHERE "+inRange,
+			"ALTER TABLE orders ATTACH PARTITION "+orders+" "+bounds,
+			"ALTER TABLE items ATTACH PARTITION "+items+" "+bounds,
+		)
+	})
+}
+
+// retirePartitions detaches the orders and items partitions for month and
+// removes the matching delivery and payment rows. With archive set, the
+// detached tables and the removed rows end up in the archive schema.
+func retirePartitions(ctx context.Context, conn *pgxpool.Conn, month time.Time, archive bool) error {
+	ordersName := partitionName("orders", month)
+	itemsName := partitionName("items", month)
+	orders := pgx.Identifier{ordersName}.Sanitize()
+	items := pgx.Identifier{itemsName}.Sanitize()
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if archive {
+			err := execAll(ctx, tx,
+				"CREATE SCHEMA IF NOT EXISTS "+ArchiveSchema,
+				fmt.Sprintf("CREATE TABLE %s AS SELECT d.* FROM delivery d JOIN %s o ON o.order_uid = d.order_uid",
+					pgx.Identifier{ArchiveSchema, partitionName("delivery", month)}.Sanitize(), orders),
+				fmt.Sprintf("CREATE TABLE %s AS SELECT p.* FROM payment p JOIN %s o ON o.order_uid = p.order_uid",
+					pgx.Identifier{ArchiveSchema, partitionName("payment", month)}.Sanitize(), orders),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := execAll(ctx, tx,
+			"DELETE FROM delivery d USING "+orders+" o WHERE d.order_uid = o.order_uid",
+			"DELETE FROM payment p USING "+orders+" o WHERE p.order_uid = o.order_uid",
+			"ALTER TABLE items DETACH PARTITION "+items,
+		)
+		if err != nil {
+			return err
+		}
+
+		// The detached items table keeps its foreign key to orders, which
+		// would block detaching the orders partition it points into.
+		rows, err := tx.Query(ctx, foreignKeysQuery, itemsName)
+		if err != nil {
+			return err
+		}
+		fks, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+		for _, fk := range fks {
+			if _, err := tx.Exec(ctx, "ALTER TABLE "+items+" DROP CONSTRAINT "+pgx.Identifier{fk}.Sanitize()); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, "ALTER TABLE orders DETACH PARTITION "+orders); err != nil {
+			return err
+		}
+
+		if archive {
+			return execAll(ctx, tx,
+				"ALTER TABLE "+orders+" SET SCHEMA "+ArchiveSchema,
+				"ALTER TABLE "+items+" SET SCHEMA "+ArchiveSchema,
+			)
+		}
+		return execAll(ctx, tx, "DROP TABLE "+items, "DROP TABLE "+orders)
+	})
+}
+
+func execAll(ctx context.Context, tx pgx.Tx, statements ...string) error {
+	for _, sql := range statements {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("%s: %w", sql, err)
+		}
+	}
+	return nil
+}
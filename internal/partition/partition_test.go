@@ -0,0 +1,96 @@
+package partition
+
+import (
+	"testing"
+	"time"
+)
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+	existing := []time.Time{
+		month(2024, time.April),
+		month(2023, time.December),
+		month(2024, time.March),
+		month(2023, time.November),
+		month(2024, time.January),
+	}
+
+	create, retire := plan(now, existing, Config{Premake: 2, Retention: 2})
+
+	wantCreate := []time.Time{month(2024, time.May)}
+	wantRetire := []time.Time{month(2023, time.November), month(2023, time.December)}
+
+	if !equalMonths(create, wantCreate) {
+		t.Errorf("create: expected %v, got %v", wantCreate, create)
+	}
+	if !equalMonths(retire, wantRetire) {
+		t.Errorf("retire: expected %v, got %v", wantRetire, retire)
+	}
+}
+
+func TestPlan_NoRetention(t *testing.T) {
+	now := time.Date(2024, time.December, 31, 23, 0, 0, 0, time.UTC)
+
+	create, retire := plan(now, []time.Time{month(2000, time.January)}, Config{Premake: 1})
+
+	if !equalMonths(create, []time.Time{month(2024, time.December), month(2025, time.January)}) {
+		t.Errorf("unexpected create: %v", create)
+	}
+	if len(retire) != 0 {
+		t.Errorf("expected nothing to retire without retention, got %v", retire)
+	}
+}
+
+func TestPartitionName(t *testing.T) {
+	name := partitionName("orders", month(2024, time.February))
+	if name != "orders_2024_02" {
+		t.Fatalf("unexpected name %q", name)
+	}
+
+	got, ok := parsePartitionName(name)
+	if !ok || !got.Equal(month(2024, time.February)) {
+		t.Fatalf("round trip failed: %v, %v", got, ok)
+	}
+
+	for _, bad := range []string{"orders_default", "orders_2024_13", "items_2024_02", "orders_2024_2"} {
+		if _, ok := parsePartitionName(bad); ok {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func equalMonths(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestManager_OnRetire(t *testing.T) {
+	m := NewManager(nil, Config{Retention: 2})
+	m.now = func() time.Time { return time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC) }
+
+	var got []time.Time
+	m.OnRetire(func(before time.Time) { got = append(got, before) })
+	m.notifyRetire()
+
+	if !equalMonths(got, []time.Time{month(2024, time.January)}) {
+		t.Errorf("OnRetire called with %v, want the start of the oldest kept month", got)
+	}
+
+	got = nil
+	m.cfg.Retention = 0
+	m.notifyRetire()
+	if len(got) != 0 {
+		t.Errorf("OnRetire called without retention: %v", got)
+	}
+}
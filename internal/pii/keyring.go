@@ -0,0 +1,190 @@
+package pii
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+// sealedPrefix marks encrypted column values; it is followed by the key ID,
+// a colon and the base64 of the nonce and ciphertext.
+const sealedPrefix = "enc:v1:"
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Keyring encrypts with its primary key and decrypts with any of its keys,
+// so keys can be rotated by adding a new primary and keeping the old ones
+// until every value has been re-encrypted. A nil *Keyring stores values in
+// plaintext.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// LoadKeyring reads a keyring file, see ParseKeyring.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keyring: %w", err)
+	}
+	k, err := ParseKeyring(data)
+	if err != nil {
+		return nil, fmt.Errorf("keyring %s: %w", path, err)
+	}
+	return k, nil
+}
+
+// ParseKeyring parses a JSON keyring of base64-encoded 256-bit AES keys by
+// ID and the ID of the key to encrypt with:
+//
+//	{"primary": "2026-10", "keys": {"2026-09": "...", "2026-10": "..."}}
+func ParseKeyring(data []byte) (*Keyring, error) {
+	var file struct {
+		Primary string            `json:"primary"`
+		Keys    map[string]string `json:"keys"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{primary: file.Primary, keys: make(map[string]cipher.AEAD, len(file.Keys))}
+	for id, encoded := range file.Keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("key ID %q may only contain letters, digits, '.', '_' and '-'", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes, base64-encoded", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[k.primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in keys", k.primary)
+	}
+	return k, nil
+}
+
+// Seal encrypts plaintext with the primary key. aad is authenticated along
+// with it, so the value only opens with the same aad.
+func (k *Keyring) Seal(plaintext, aad string) (string, error) {
+	if k == nil {
+		return plaintext, nil
+	}
+	aead := k.keys[k.primary]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return sealedPrefix + k.primary + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value written by Seal. Other values were stored before
+// encryption was enabled and are returned as they are. A nil *Keyring
+// returns every value as it is: without encryption a value starting with
+// the sealed prefix is ordinary data.
+func (k *Keyring) Open(value, aad string) (string, error) {
+	rest, ok := strings.CutPrefix(value, sealedPrefix)
+	if !ok || k == nil {
+		return value, nil
+	}
+
+	id, encoded, _ := strings.Cut(rest, ":")
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("value is encrypted with unknown key %q", id)
+	}
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	n := aead.NonceSize()
+	plaintext, err := aead.Open(nil, data[:n], data[n:], []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("decrypt with key %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// current reports whether value is encrypted with the primary key.
+func (k *Keyring) current(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix+k.primary+":")
+}
+
+type field struct {
+	name  string
+	value *string
+}
+
+// deliveryFields are the encrypted delivery columns. Zip, city and region
+// are kept in plaintext.
+func deliveryFields(d *models.Delivery) []field {
+	return []field{
+		{"name", &d.Name},
+		{"phone", &d.Phone},
+		{"email", &d.Email},
+		{"address", &d.Address},
+	}
+}
+
+// fieldAAD binds an encrypted value to its order and column, so that values
+// copied to another row or column fail to decrypt.
+func fieldAAD(orderUID, column string) string {
+	return orderUID + "/delivery." + column
+}
+
+// SealDelivery returns d with its PII fields encrypted for order orderUID.
+func (k *Keyring) SealDelivery(orderUID string, d models.Delivery) (models.Delivery, error) {
+	for _, f := range deliveryFields(&d) {
+		sealed, err := k.Seal(*f.value, fieldAAD(orderUID, f.name))
+		if err != nil {
+			return models.Delivery{}, fmt.Errorf("encrypt delivery %s: %w", f.name, err)
+		}
+		*f.value = sealed
+	}
+	return d, nil
+}
+
+// OpenDelivery decrypts the PII fields of d, read for order orderUID, in
+// place.
+func (k *Keyring) OpenDelivery(orderUID string, d *models.Delivery) error {
+	for _, f := range deliveryFields(d) {
+		plaintext, err := k.Open(*f.value, fieldAAD(orderUID, f.name))
+		if err != nil {
+			return fmt.Errorf("decrypt delivery %s: %w", f.name, err)
+		}
+		*f.value = plaintext
+	}
+	return nil
+}
+
+// DeliveryCurrent reports whether every PII field of d is encrypted with
+// the primary key, i.e. whether re-encrypting d would change nothing.
+func (k *Keyring) DeliveryCurrent(d models.Delivery) bool {
+	for _, f := range deliveryFields(&d) {
+		if !k.current(*f.value) {
+			return false
+		}
+	}
+	return true
+}
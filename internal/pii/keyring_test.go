@@ -0,0 +1,128 @@
+package pii
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func keyring(t *testing.T, primary string, keys map[string]string) *Keyring {
+	t.Helper()
+	var entries []string
+	for id, key := range keys {
+		entries = append(entries, fmt.Sprintf("%q: %q", id, key))
+	}
+	k, err := ParseKeyring([]byte(fmt.Sprintf(`{"primary": %q, "keys": {%s}}`, primary, strings.Join(entries, ", "))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	k := keyring(t, "k1", map[string]string{"k1": newKey(t)})
+
+	sealed, err := k.Seal("+79161231234", "uid/delivery.phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, "enc:v1:k1:") || strings.Contains(sealed, "9161231234") {
+		t.Fatalf("sealed value = %q", sealed)
+	}
+	again, _ := k.Seal("+79161231234", "uid/delivery.phone")
+	if again == sealed {
+		t.Error("sealing twice gave the same ciphertext")
+	}
+
+	got, err := k.Open(sealed, "uid/delivery.phone")
+	if err != nil || got != "+79161231234" {
+		t.Errorf("Open = %q, %v", got, err)
+	}
+	if _, err := k.Open(sealed, "other/delivery.phone"); err == nil {
+		t.Error("value opened with another order's aad")
+	}
+	if got, err := k.Open("plain", "uid/delivery.phone"); err != nil || got != "plain" {
+		t.Errorf("Open(plaintext) = %q, %v", got, err)
+	}
+
+	var none *Keyring
+	if got, err := none.Seal("plain", ""); err != nil || got != "plain" {
+		t.Errorf("nil keyring Seal = %q, %v", got, err)
+	}
+	// Without a keyring nothing is encrypted, so data that happens to
+	// look sealed is plaintext.
+	for _, v := range []string{"enc:v1:", "enc:v1:k1:not base64", sealed} {
+		if got, err := none.Open(v, "uid/delivery.phone"); err != nil || got != v {
+			t.Errorf("nil keyring Open(%q) = %q, %v", v, got, err)
+		}
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKey, newKeyValue := newKey(t), newKey(t)
+	before := keyring(t, "2026-09", map[string]string{"2026-09": oldKey})
+	after := keyring(t, "2026-10", map[string]string{"2026-09": oldKey, "2026-10": newKeyValue})
+
+	d := models.Delivery{Name: "Ivan Petrov", Phone: "+79161231234", Email: "ivan@example.com", Address: "Lenina 1", City: "Moscow"}
+	sealed, err := before.SealDelivery("uid", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.City != "Moscow" || sealed.Name == d.Name {
+		t.Fatalf("sealed delivery = %+v", sealed)
+	}
+	if !before.DeliveryCurrent(sealed) || after.DeliveryCurrent(sealed) {
+		t.Error("DeliveryCurrent does not follow the primary key")
+	}
+	if after.DeliveryCurrent(d) {
+		t.Error("plaintext delivery reported as current")
+	}
+
+	// The new keyring still opens values sealed with the old key.
+	opened := sealed
+	if err := after.OpenDelivery("uid", &opened); err != nil {
+		t.Fatal(err)
+	}
+	if opened != d {
+		t.Errorf("opened = %+v, want %+v", opened, d)
+	}
+
+	resealed, err := after.SealDelivery("uid", opened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.DeliveryCurrent(resealed) {
+		t.Errorf("resealed delivery not current: %+v", resealed)
+	}
+	if err := before.OpenDelivery("uid", &resealed); err == nil {
+		t.Error("old keyring opened a value sealed with the new key")
+	}
+}
+
+func TestParseKeyring_Errors(t *testing.T) {
+	key := newKey(t)
+	for name, doc := range map[string]string{
+		"not json":        `{`,
+		"unknown field":   `{"primary": "a", "keys": {"a": "` + key + `"}, "extra": 1}`,
+		"missing primary": `{"primary": "b", "keys": {"a": "` + key + `"}}`,
+		"short key":       `{"primary": "a", "keys": {"a": "c2hvcnQ="}}`,
+		"bad key id":      `{"primary": "a:b", "keys": {"a:b": "` + key + `"}}`,
+	} {
+		if _, err := ParseKeyring([]byte(doc)); err == nil {
+			t.Errorf("%s: ParseKeyring succeeded, want error", name)
+		}
+	}
+}
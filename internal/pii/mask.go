@@ -0,0 +1,88 @@
+// Package pii masks, scrubs and encrypts the personal data orders carry:
+// the name, phone, email and address of the delivery.
+package pii
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+const stars = "*****"
+
+// MaskPhone keeps the country code digit and the last four digits, e.g.
+// +79161234567 becomes +7*****4567. The number of hidden digits is not
+// revealed.
+func MaskPhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) < 7 {
+		return stars
+	}
+
+	var prefix string
+	if strings.HasPrefix(strings.TrimSpace(phone), "+") {
+		prefix = "+"
+	}
+	return prefix + digits[:1] + stars + digits[len(digits)-4:]
+}
+
+// MaskEmail keeps the first character of the local part and the domain, e.g.
+// john@example.com becomes j***@example.com.
+func MaskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return MaskWords(email)
+	}
+	first, _ := utf8.DecodeRuneInString(email)
+	return string(first) + "***" + email[at:]
+}
+
+// MaskWords keeps the first character of every word, e.g. Ivan Petrov
+// becomes I*** P***. It is used for names and addresses.
+func MaskWords(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	})
+	for i, w := range words {
+		first, _ := utf8.DecodeRuneInString(w)
+		words[i] = string(first) + "***"
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskOrder returns a copy of order with the delivery PII masked. order
+// itself, which may be shared with the cache, is left untouched.
+func MaskOrder(order *models.Order) *models.Order {
+	masked := *order
+	d := &masked.Delivery
+	d.Name = MaskWords(d.Name)
+	d.Phone = MaskPhone(d.Phone)
+	d.Email = MaskEmail(d.Email)
+	d.Address = MaskWords(d.Address)
+	return &masked
+}
+
+// Redacted replaces personal data in records that outlive the order data,
+// such as audit log entries.
+const Redacted = "[redacted]"
+
+// RedactOrder returns a copy of order with the delivery PII replaced by
+// Redacted. Unlike MaskOrder nothing of the original values is kept, so the
+// copy stays free of personal data after the customer is erased.
+func RedactOrder(order *models.Order) *models.Order {
+	redacted := *order
+	d := &redacted.Delivery
+	for _, v := range []*string{&d.Name, &d.Phone, &d.Email, &d.Address} {
+		if *v != "" {
+			*v = Redacted
+		}
+	}
+	return &redacted
+}
@@ -0,0 +1,86 @@
+package pii
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name string
+		mask func(string) string
+		in   string
+		want string
+	}{
+		{"phone", MaskPhone, "+79161231234", "+7*****1234"},
+		{"formatted phone", MaskPhone, "+7 (916) 123-12-34", "+7*****1234"},
+		{"local phone", MaskPhone, "89161231234", "8*****1234"},
+		{"short phone", MaskPhone, "12345", "*****"},
+		{"email", MaskEmail, "john.doe@example.com", "j***@example.com"},
+		{"email without local part", MaskEmail, "@example.com", "@***"},
+		{"name", MaskWords, "Ivan Petrov", "I*** P***"},
+		{"cyrillic name", MaskWords, "Иван Петров", "И*** П***"},
+		{"address", MaskWords, "Kiryat Mozkin, 15", "K*** M*** 1***"},
+		{"empty", MaskWords, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mask(tt.in); got != tt.want {
+				t.Errorf("mask(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskOrder_LeavesOriginal(t *testing.T) {
+	order := &models.Order{
+		OrderUID: "b563feb7b2b84b6test",
+		Delivery: models.Delivery{Name: "Test Testov", Phone: "+9720000000", Email: "test@gmail.com", Address: "Ploshad Mira 15", City: "Kiryat Mozkin"},
+	}
+
+	masked := MaskOrder(order)
+	want := models.Delivery{Name: "T*** T***", Phone: "+9*****0000", Email: "t***@gmail.com", Address: "P*** M*** 1***", City: "Kiryat Mozkin"}
+	if masked.Delivery != want {
+		t.Errorf("masked delivery = %+v, want %+v", masked.Delivery, want)
+	}
+	if order.Delivery.Name != "Test Testov" || order.Delivery.Phone != "+9720000000" {
+		t.Errorf("original modified: %+v", order.Delivery)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	in := `order b563feb7-b2b8-4b6e-a000-000000000001 offset 1234567890: invalid email format for test@gmail.com, call +7 916 123-12-34 at 2026-10-19T12:00:00+03:00`
+	got := Scrub(in)
+
+	for _, leaked := range []string{"test@gmail.com", "916 123"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("Scrub left %q in %q", leaked, got)
+		}
+	}
+	for _, kept := range []string{"b563feb7-b2b8-4b6e-a000-000000000001", "offset 1234567890", "t***@gmail.com", "+7*****1234", "+03:00"} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("Scrub(%q) = %q, want it to contain %q", in, got, kept)
+		}
+	}
+	if again := Scrub(got); again != got {
+		t.Errorf("Scrub is not idempotent: %q -> %q", got, again)
+	}
+}
+
+func TestRedactOrder(t *testing.T) {
+	order := &models.Order{
+		OrderUID: "b563feb7b2b84b6test",
+		Delivery: models.Delivery{Name: "Test Testov", Phone: "+9720000000", Email: "test@gmail.com", City: "Kiryat Mozkin"},
+	}
+
+	redacted := RedactOrder(order)
+	want := models.Delivery{Name: Redacted, Phone: Redacted, Email: Redacted, City: "Kiryat Mozkin"}
+	if redacted.Delivery != want {
+		t.Errorf("redacted delivery = %+v, want %+v", redacted.Delivery, want)
+	}
+	if order.Delivery.Name != "Test Testov" {
+		t.Errorf("original modified: %+v", order.Delivery)
+	}
+}
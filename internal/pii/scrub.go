@@ -0,0 +1,18 @@
+package pii
+
+import "regexp"
+
+var (
+	emailPattern = regexp.MustCompile(`[\w.%+\-]+@[\w\-]+(\.[\w\-]+)*\.[A-Za-z]{2,}`)
+	// Only numbers in international format are recognised: without the
+	// leading + a phone cannot be told apart from an offset or an ID.
+	phonePattern = regexp.MustCompile(`\+[0-9][0-9 ()\-]{6,19}[0-9]`)
+)
+
+// Scrub masks email addresses and phone numbers in international format
+// found anywhere in s. Names and addresses cannot be recognised in free
+// text, so code must not put them into messages in the first place.
+func Scrub(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+	return phonePattern.ReplaceAllStringFunc(s, MaskPhone)
+}
@@ -0,0 +1,196 @@
+// Package ratelimit throttles API callers with a token bucket per caller:
+// per API key or token subject for authenticated requests and per client
+// IP otherwise. Failed authentication attempts are limited per client IP.
+package ratelimit
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/auth"
+	"github.com/sonni-a/wb-service/internal/metrics"
+)
+
+// sweepInterval is how often buckets that have refilled completely, and so
+// are no different from a new one, are dropped.
+const sweepInterval = time.Minute
+
+// Limiter allows each caller rate requests per second on average and up to
+// burst at once. A zero rate disables limiting.
+type Limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func New(rate, burst int) *Limiter {
+	l := &Limiter{buckets: make(map[string]*bucket), now: time.Now}
+	l.SetLimit(rate, burst)
+	return l
+}
+
+// SetLimit changes the rate and burst for requests from now on. Callers
+// keep the tokens they have, capped at the new burst.
+func (l *Limiter) SetLimit(rate, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(rate)
+	l.burst = float64(burst)
+	for _, b := range l.buckets {
+		b.tokens = min(b.tokens, l.burst)
+	}
+}
+
+// Allow takes a token from key's bucket. If there is none, it returns
+// false and how long until the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	return l.take(key, true)
+}
+
+// Check reports whether key's bucket has a token, like Allow, without
+// taking it.
+func (l *Limiter) Check(key string) (bool, time.Duration) {
+	return l.take(key, false)
+}
+
+func (l *Limiter) take(key string, consume bool) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	if consume {
+		b.tokens--
+	}
+	return true, 0
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Limit rejects requests over the caller's limit with 429 and a
+// Retry-After header. It must run after authentication so that
+// authenticated callers are limited by identity rather than by IP.
+func (l *Limiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, kind := callerKey(r)
+		ok, wait := l.Allow(key)
+		if ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		reject(w, r, key, kind, wait)
+	})
+}
+
+// LimitFailures must run before authentication. Every request that next
+// answers with 401 takes a token from the bucket of the client IP; once it
+// is empty, requests from that IP are rejected with 429 without being
+// authenticated, so guessing keys or forging tokens costs the service no
+// more than the limit allows. Requests that authenticate take no token
+// here and are limited by Limit instead.
+func (l *Limiter) LimitFailures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := ipKey(r)
+		if ok, wait := l.Check(key); !ok {
+			reject(w, r, key, "ip", wait)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == http.StatusUnauthorized {
+			l.Allow(key)
+		}
+	})
+}
+
+func reject(w http.ResponseWriter, r *http.Request, key, kind string, wait time.Duration) {
+	metrics.HttpRateLimitedTotal.WithLabelValues(kind).Inc()
+	slog.InfoContext(r.Context(), "Rate limit exceeded", "caller", key)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_ = json.NewEncoder(w).Encode(auth.Error{Error: "rate_limited", Message: "too many requests, retry later"})
+}
+
+// statusWriter records the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// imports and exports use to lift their deadlines.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// callerKey identifies the caller of r and says whether it is an
+// authenticated principal ("principal") or a client IP ("ip").
+func callerKey(r *http.Request) (string, string) {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return "principal:" + p.Subject, "principal"
+	}
+	return ipKey(r), "ip"
+}
+
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(rate, burst int) (*Limiter, *time.Time) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	l := New(rate, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, now := newTestLimiter(2, 3)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d within the burst was rejected", i+1)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("Allow after the burst = %v, %v; want false, 500ms", ok, wait)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("another caller was limited by a's requests")
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("token not refilled after 1/rate seconds")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("more than one token refilled")
+	}
+}
+
+func TestLimiter_SetLimit(t *testing.T) {
+	l, _ := newTestLimiter(0, 1)
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatal("a zero rate must not limit")
+		}
+	}
+
+	l.SetLimit(1, 1)
+	l.Allow("a")
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("new limit not applied")
+	}
+}
+
+func TestLimiter_SweepsIdleBuckets(t *testing.T) {
+	l, now := newTestLimiter(1, 5)
+	l.Allow("a")
+	l.Allow("b")
+
+	*now = now.Add(sweepInterval)
+	l.Allow("b")
+	if _, ok := l.buckets["a"]; ok || len(l.buckets) != 1 {
+		t.Errorf("buckets after sweep: %v", l.buckets)
+	}
+}
+
+func TestLimiter_Limit(t *testing.T) {
+	l, _ := newTestLimiter(1, 1)
+	h := l.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/order/1", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	w := serve("10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("second request from the same IP: %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("request from another IP: %d", w.Code)
+	}
+}
+
+func TestLimiter_LimitFailures(t *testing.T) {
+	l, now := newTestLimiter(1, 2)
+	authenticated := 0
+	h := l.LimitFailures(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated++
+		if r.Header.Get("X-API-Key") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+
+	serve := func(remoteAddr, key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/order/1", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 5; i++ {
+		if code := serve("10.0.0.1:1234", "good"); code != http.StatusOK {
+			t.Fatalf("authenticated request %d: %d", i+1, code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if code := serve("10.0.0.1:1234", "guess"); code != http.StatusUnauthorized {
+			t.Fatalf("failed attempt %d within the burst: %d", i+1, code)
+		}
+	}
+
+	authenticated = 0
+	if code := serve("10.0.0.1:5678", "guess"); code != http.StatusTooManyRequests {
+		t.Errorf("failed attempt over the limit: %d", code)
+	}
+	if authenticated != 0 {
+		t.Error("requests over the limit must not reach authentication")
+	}
+	if code := serve("10.0.0.2:1234", "guess"); code != http.StatusUnauthorized {
+		t.Errorf("failed attempt from another IP: %d", code)
+	}
+
+	*now = now.Add(time.Second)
+	if code := serve("10.0.0.1:1234", "good"); code != http.StatusOK {
+		t.Errorf("request after a token was refilled: %d", code)
+	}
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sonni-a/wb-service/internal/audit"
+	"github.com/sonni-a/wb-service/internal/metrics"
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+// AuditFilter narrows down the audit log entries returned by List. Zero
+// values mean "no restriction".
+type AuditFilter struct {
+	Actor    string
+	Source   string
+	Action   string
+	OrderUID string
+	From     time.Time
+	To       time.Time
+	// BeforeID returns entries older than the entry with this ID, for
+	// paging through the log newest first.
+	BeforeID int64
+	// Limit caps the number of entries; it must be positive.
+	Limit int
+}
+
+func (f AuditFilter) whereClause() (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, strings.Replace(cond, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+
+	if f.Actor != "" {
+		add("actor = ?", f.Actor)
+	}
+	if f.Source != "" {
+		add("source = ?", f.Source)
+	}
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.OrderUID != "" {
+		add("order_uid = ?::uuid", f.OrderUID)
+	}
+	if !f.From.IsZero() {
+		add("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at < ?", f.To)
+	}
+	if f.BeforeID > 0 {
+		add("id < ?", f.BeforeID)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return "\nWHERE " + strings.Join(conds, " AND "), args
+}
+
+type AuditRepo interface {
+	audit.Recorder
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error)
+}
+
+// AuditRepository reads the audit log and records changes made outside
+// the order repository. Order changes are recorded by OrderRepository in
+// the transaction that makes them.
+type AuditRepository struct {
+	db *pgxpool.Pool
+}
+
+var _ AuditRepo = (*AuditRepository)(nil)
+
+func NewAuditRepository(db *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Record appends entry to the audit log, attributed to the actor in ctx.
+func (r *AuditRepository) Record(ctx context.Context, entry models.AuditEntry) error {
+	start := time.Now()
+	defer func() {
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("record_audit"), time.Since(start).Seconds())
+	}()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertAuditTx(ctx, tx, entry); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// List returns the entries matching filter, newest first. It reads from
+// the primary, so entries are visible as soon as the change is committed.
+func (r *AuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("list_audit"), time.Since(start).Seconds())
+	}()
+
+	where, args := filter.whereClause()
+	args = append(args, filter.Limit)
+	query := ListAuditQuery + where + "\nORDER BY id DESC\nLIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var (
+			e    models.AuditEntry
+			diff []byte
+		)
+		if err := rows.Scan(&e.ID, &e.CreatedAt, &e.Actor, &e.Source, &e.Action, &e.OrderUID, &diff); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		if diff != nil {
+			if err := json.Unmarshal(diff, &e.Diff); err != nil {
+				return nil, fmt.Errorf("audit entry %d: decode diff: %w", e.ID, err)
+			}
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// insertAuditTx appends entry to the audit log, attributed to the actor in
+// ctx.
+func insertAuditTx(ctx context.Context, tx pgx.Tx, entry models.AuditEntry) error {
+	actor := audit.ActorFrom(ctx)
+
+	var diff any
+	if len(entry.Diff) > 0 {
+		data, err := json.Marshal(entry.Diff)
+		if err != nil {
+			return fmt.Errorf("marshal audit diff: %w", err)
+		}
+		diff = string(data)
+	}
+
+	if _, err := tx.Exec(ctx, InsertAuditQuery,
+		actor.Name, actor.Source, entry.Action, entry.OrderUID, diff); err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
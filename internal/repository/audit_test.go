@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditFilter_WhereClause(t *testing.T) {
+	if where, args := (AuditFilter{Limit: 10}).whereClause(); where != "" || len(args) != 0 {
+		t.Errorf("empty filter: %q %v", where, args)
+	}
+
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	where, args := AuditFilter{Source: "kafka", OrderUID: "550e8400-e29b-41d4-a716-446655440000", From: from, BeforeID: 7}.whereClause()
+	if want := "\nWHERE source = $1 AND order_uid = $2::uuid AND created_at >= $3 AND id < $4"; where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	if len(args) != 4 || args[0] != "kafka" || args[2] != from || args[3] != int64(7) {
+		t.Errorf("args = %v", args)
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OrderFilter narrows down the set of orders returned by bulk queries.
+// Zero values mean "no restriction".
+type OrderFilter struct {
+	CustomerID      string
+	DeliveryService string
+	From            time.Time
+	To              time.Time
+}
+
+// whereClause returns the WHERE clause for orders and extra conditions for
+// the items join. The date range is repeated on items so that PostgreSQL
+// prunes items partitions as well as orders partitions.
+func (f OrderFilter) whereClause() (string, string, []any) {
+	var (
+		conds      []string
+		itemsConds []string
+		args       []any
+	)
+
+	add := func(cond string, arg any) string {
+		args = append(args, arg)
+		return strings.Replace(cond, "?", "$"+strconv.Itoa(len(args)), 1)
+	}
+
+	if f.CustomerID != "" {
+		conds = append(conds, add("o.customer_id = ?", f.CustomerID))
+	}
+	if f.DeliveryService != "" {
+		conds = append(conds, add("o.delivery_service = ?", f.DeliveryService))
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, add("o.date_created >= ?", f.From))
+		itemsConds = append(itemsConds, "i.date_created >= $"+strconv.Itoa(len(args)))
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, add("o.date_created < ?", f.To))
+		itemsConds = append(itemsConds, "i.date_created < $"+strconv.Itoa(len(args)))
+	}
+
+	var where, itemsCond string
+	if len(conds) > 0 {
+		where = "\nWHERE " + strings.Join(conds, " AND ")
+	}
+	if len(itemsConds) > 0 {
+		itemsCond = " AND " + strings.Join(itemsConds, " AND ")
+	}
+	return where, itemsCond, args
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: C:/Users/HONOR/Desktop/wb-service/internal/repository/order.go
+// Source: internal/repository/order.go
 
 // Package mock_repository is a generated GoMock package.
 package mock_repository
@@ -10,6 +10,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/sonni-a/wb-service/internal/models"
+	repository "github.com/sonni-a/wb-service/internal/repository"
 )
 
 // MockOrderRepo is a mock of OrderRepo interface.
@@ -35,6 +36,21 @@ func (m *MockOrderRepo) EXPECT() *MockOrderRepoMockRecorder {
 	return m.recorder
 }
 
+// EraseCustomer mocks base method.
+func (m *MockOrderRepo) EraseCustomer(ctx context.Context, customerID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseCustomer", ctx, customerID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EraseCustomer indicates an expected call of EraseCustomer.
+func (mr *MockOrderRepoMockRecorder) EraseCustomer(ctx, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseCustomer", reflect.TypeOf((*MockOrderRepo)(nil).EraseCustomer), ctx, customerID)
+}
+
 // GetAllOrders mocks base method.
 func (m *MockOrderRepo) GetAllOrders(ctx context.Context) ([]*models.Order, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +94,32 @@ func (mr *MockOrderRepoMockRecorder) InsertOrder(ctx, order interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrder", reflect.TypeOf((*MockOrderRepo)(nil).InsertOrder), ctx, order)
 }
+
+// InsertOrders mocks base method.
+func (m *MockOrderRepo) InsertOrders(ctx context.Context, orders []*models.Order) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertOrders", ctx, orders)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertOrders indicates an expected call of InsertOrders.
+func (mr *MockOrderRepoMockRecorder) InsertOrders(ctx, orders interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrders", reflect.TypeOf((*MockOrderRepo)(nil).InsertOrders), ctx, orders)
+}
+
+// StreamOrders mocks base method.
+func (m *MockOrderRepo) StreamOrders(ctx context.Context, filter repository.OrderFilter, fn func(*models.Order) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamOrders", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamOrders indicates an expected call of StreamOrders.
+func (mr *MockOrderRepoMockRecorder) StreamOrders(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamOrders", reflect.TypeOf((*MockOrderRepo)(nil).StreamOrders), ctx, filter, fn)
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sonni-a/wb-service/internal/logging"
 	"github.com/sonni-a/wb-service/internal/metrics"
 	"github.com/sonni-a/wb-service/internal/models"
+	"github.com/sonni-a/wb-service/internal/pii"
 	"github.com/sonni-a/wb-service/internal/validator"
 )
 
@@ -18,10 +21,30 @@ type OrderRepo interface {
 	InsertOrder(ctx context.Context, order *models.Order) error
 	GetOrder(ctx context.Context, uid string) (*models.Order, error)
 	GetAllOrders(ctx context.Context) ([]*models.Order, error)
+	InsertOrders(ctx context.Context, orders []*models.Order) ([]error, error)
+	StreamOrders(ctx context.Context, filter OrderFilter, fn func(*models.Order) error) error
+	EraseCustomer(ctx context.Context, customerID string) ([]string, error)
 }
 
+// ErasedValue replaces erased personal data.
+const ErasedValue = "[erased]"
+
+// Audited actions.
+const (
+	ActionCreateOrder   = "order.create"
+	ActionEraseCustomer = "customer.erase"
+	ActionRekeyDelivery = "delivery.rekey"
+)
+
 type OrderRepository struct {
 	db *pgxpool.Pool
+
+	// replicas and recent are nil unless read replicas are configured.
+	replicas ReadRouter
+	recent   *recentWrites
+
+	// keyring encrypts delivery PII; nil stores it in plaintext.
+	keyring *pii.Keyring
 }
 
 var _ OrderRepo = (*OrderRepository)(nil)
@@ -30,12 +53,58 @@ func NewOrderRepository(db *pgxpool.Pool) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
+// NewReplicatedOrderRepository writes to the primary and reads from
+// replicas, falling back to the primary when a replica query fails. Orders
+// written through this repository are read from the primary for the
+// router's MaxLag, so they are visible right after being created.
+func NewReplicatedOrderRepository(router ReadRouter) *OrderRepository {
+	return &OrderRepository{
+		db:       router.Primary(),
+		replicas: router,
+		recent:   newRecentWrites(router.MaxLag()),
+	}
+}
+
+// SetKeyring makes the repository encrypt the delivery name, phone, email
+// and address it writes and decrypt them on reads. It must be called before
+// the repository is used.
+func (r *OrderRepository) SetKeyring(k *pii.Keyring) {
+	r.keyring = k
+}
+
+// reader returns the pool for reading order uid, or for bulk reads when uid
+// is empty.
+func (r *OrderRepository) reader(uid string) *pgxpool.Pool {
+	if r.replicas == nil || (uid != "" && r.recent.contains(uid)) {
+		return r.db
+	}
+	return r.replicas.Reader()
+}
+
+// retryOnPrimary reports whether a read that failed on pool should be
+// repeated on the primary, taking the replica out of rotation if so.
+func (r *OrderRepository) retryOnPrimary(ctx context.Context, pool *pgxpool.Pool, err error) bool {
+	if pool == r.db || errors.Is(err, ErrOrderNotFound) || ctx.Err() != nil {
+		return false
+	}
+	slog.WarnContext(ctx, "Read replica query failed, retrying on primary", "error", err)
+	r.replicas.MarkUnhealthy(pool)
+	return true
+}
+
+func (r *OrderRepository) rememberWrites(orders ...*models.Order) {
+	if r.recent == nil {
+		return
+	}
+	for _, order := range orders {
+		r.recent.add(order.OrderUID)
+	}
+}
+
 func (r *OrderRepository) InsertOrder(ctx context.Context, order *models.Order) error {
 	start := time.Now()
 	defer func() {
-		metrics.DBQueryDuration.
-			WithLabelValues("insert_order").
-			Observe(time.Since(start).Seconds())
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("insert_order"), time.Since(start).Seconds())
 	}()
 
 	if err := validator.ValidateOrder(order); err != nil {
@@ -49,6 +118,80 @@ func (r *OrderRepository) InsertOrder(ctx context.Context, order *models.Order)
 
 	defer tx.Rollback(ctx)
 
+	if err = insertOrderTx(ctx, tx, r.keyring, order); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	r.rememberWrites(order)
+	slog.DebugContext(ctx, "Order inserted", logging.Duration(time.Since(start)))
+
+	return nil
+}
+
+// InsertOrders stores a batch of orders in a single transaction. Every order
+// is inserted under its own savepoint, so a duplicate or invalid order only
+// rolls back itself; per-order failures are returned in results (nil on
+// success) while err reports failures of the batch as a whole.
+func (r *OrderRepository) InsertOrders(ctx context.Context, orders []*models.Order) ([]error, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("insert_orders"), time.Since(start).Seconds())
+	}()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("start transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	results := make([]error, len(orders))
+	for i, order := range orders {
+		if err := validator.ValidateOrder(order); err != nil {
+			results[i] = fmt.Errorf("order validation failed: %w", err)
+			continue
+		}
+
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("start savepoint: %w", err)
+		}
+
+		if err := insertOrderTx(ctx, sp, r.keyring, order); err != nil {
+			results[i] = err
+			if rbErr := sp.Rollback(ctx); rbErr != nil {
+				return nil, fmt.Errorf("rollback savepoint: %w", rbErr)
+			}
+			continue
+		}
+
+		if err := sp.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("release savepoint: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	for i, order := range orders {
+		if results[i] == nil {
+			r.rememberWrites(order)
+		}
+	}
+
+	return results, nil
+}
+
+func insertOrderTx(ctx context.Context, tx pgx.Tx, keyring *pii.Keyring, order *models.Order) error {
+	delivery, err := keyring.SealDelivery(order.OrderUID, order.Delivery)
+	if err != nil {
+		return err
+	}
+
 	_, err = tx.Exec(ctx, InsertOrderQuery,
 		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
 		order.CustomerID, order.DeliveryService, order.ShardKey, order.SmID, order.DateCreated, order.OofShard)
@@ -57,8 +200,8 @@ func (r *OrderRepository) InsertOrder(ctx context.Context, order *models.Order)
 	}
 
 	_, err = tx.Exec(ctx, InsertDeliveryQuery,
-		order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
-		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email)
+		order.OrderUID, delivery.Name, delivery.Phone, delivery.Zip,
+		delivery.City, delivery.Address, delivery.Region, delivery.Email)
 	if err != nil {
 		return mapInsertError(err, "insert delivery")
 	}
@@ -73,31 +216,47 @@ func (r *OrderRepository) InsertOrder(ctx context.Context, order *models.Order)
 
 	for _, item := range order.Items {
 		_, err = tx.Exec(ctx, InsertItemQuery,
-			order.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.RID, item.Name,
+			order.OrderUID, order.DateCreated, item.ChrtID, item.TrackNumber, item.Price, item.RID, item.Name,
 			item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, item.Status)
 		if err != nil {
 			return mapInsertError(err, "insert item")
 		}
 	}
 
-	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("commit transaction: %w", err)
-	}
-
-	return nil
+	// The delivery PII is redacted so that the entry does not outlive an
+	// erasure of the customer.
+	return insertAuditTx(ctx, tx, models.AuditEntry{
+		Action:   ActionCreateOrder,
+		OrderUID: &order.OrderUID,
+		Diff:     map[string]models.FieldChange{"order": {After: pii.RedactOrder(order)}},
+	})
 }
 
 func (r *OrderRepository) GetOrder(ctx context.Context, orderUID string) (*models.Order, error) {
 	start := time.Now()
 	defer func() {
-		metrics.DBQueryDuration.
-			WithLabelValues("get_order").
-			Observe(time.Since(start).Seconds())
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("get_order"), time.Since(start).Seconds())
 	}()
 
+	pool := r.reader(orderUID)
+	order, err := getOrder(ctx, pool, orderUID)
+	if err != nil && r.retryOnPrimary(ctx, pool, err) {
+		order, err = getOrder(ctx, r.db, orderUID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.keyring.OpenDelivery(order.OrderUID, &order.Delivery); err != nil {
+		return nil, fmt.Errorf("order %s: %w", order.OrderUID, err)
+	}
+	return order, nil
+}
+
+func getOrder(ctx context.Context, pool *pgxpool.Pool, orderUID string) (*models.Order, error) {
 	order := &models.Order{}
 
-	err := r.db.QueryRow(ctx, GetOrderWithJoinsQuery, orderUID).Scan(
+	err := pool.QueryRow(ctx, GetOrderWithJoinsQuery, orderUID).Scan(
 		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale,
 		&order.InternalSignature, &order.CustomerID, &order.DeliveryService,
 		&order.ShardKey, &order.SmID, &order.DateCreated, &order.OofShard,
@@ -119,7 +278,8 @@ func (r *OrderRepository) GetOrder(ctx context.Context, orderUID string) (*model
 	order.Delivery.OrderUID = order.OrderUID
 	order.Payment.OrderUID = order.OrderUID
 
-	rows, err := r.db.Query(ctx, GetItemsQuery, orderUID)
+	// Passing date_created lets PostgreSQL read a single items partition.
+	rows, err := pool.Query(ctx, GetItemsQuery, orderUID, order.DateCreated)
 	if err != nil {
 		return nil, fmt.Errorf("get items: %w", err)
 	}
@@ -136,6 +296,9 @@ func (r *OrderRepository) GetOrder(ctx context.Context, orderUID string) (*model
 		item.OrderUID = order.OrderUID
 		order.Items = append(order.Items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate items: %w", err)
+	}
 
 	return order, nil
 }
@@ -143,12 +306,28 @@ func (r *OrderRepository) GetOrder(ctx context.Context, orderUID string) (*model
 func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]*models.Order, error) {
 	start := time.Now()
 	defer func() {
-		metrics.DBQueryDuration.
-			WithLabelValues("get_all_orders").
-			Observe(time.Since(start).Seconds())
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("get_all_orders"), time.Since(start).Seconds())
 	}()
 
-	rows, err := r.db.Query(ctx, GetAllOrdersWithJoinsQuery)
+	pool := r.reader("")
+	orders, err := getAllOrders(ctx, pool)
+	if err != nil && r.retryOnPrimary(ctx, pool, err) {
+		orders, err = getAllOrders(ctx, r.db)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		if err := r.keyring.OpenDelivery(order.OrderUID, &order.Delivery); err != nil {
+			return nil, fmt.Errorf("order %s: %w", order.OrderUID, err)
+		}
+	}
+	return orders, nil
+}
+
+func getAllOrders(ctx context.Context, pool *pgxpool.Pool) ([]*models.Order, error) {
+	rows, err := pool.Query(ctx, GetAllOrdersWithJoinsQuery)
 	if err != nil {
 		return nil, fmt.Errorf("query all orders with joins: %w", err)
 	}
@@ -183,7 +362,7 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]*models.Order, er
 		return nil, fmt.Errorf("iterate orders: %w", err)
 	}
 
-	itemRows, err := r.db.Query(ctx, GetAllItemsQuery)
+	itemRows, err := pool.Query(ctx, GetAllItemsQuery)
 	if err != nil {
 		return nil, fmt.Errorf("query all items: %w", err)
 	}
@@ -209,6 +388,294 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]*models.Order, er
 	return orders, nil
 }
 
+// StreamOrders walks over orders matching filter using a server-side cursor
+// and calls fn for every fully assembled order, so the result set is never
+// held in memory as a whole. If the cursor cannot be opened on a replica, it is opened on the
+// primary; a failure after that fails the stream, as orders may have been
+// passed to fn already.
+func (r *OrderRepository) StreamOrders(ctx context.Context, filter OrderFilter, fn func(*models.Order) error) error {
+	start := time.Now()
+	defer func() {
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("stream_orders"), time.Since(start).Seconds())
+	}()
+
+	where, itemsCond, args := filter.whereClause()
+	query := DeclareStreamCursorQuery + StreamOrdersSelect + itemsCond + where + StreamOrdersOrderBy
+
+	pool := r.reader("")
+	tx, err := openCursor(ctx, pool, query, args)
+	if err != nil && r.retryOnPrimary(ctx, pool, err) {
+		tx, err = openCursor(ctx, r.db, query, args)
+	}
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	emit := func(order *models.Order) error {
+		if err := r.keyring.OpenDelivery(order.OrderUID, &order.Delivery); err != nil {
+			return fmt.Errorf("order %s: %w", order.OrderUID, err)
+		}
+		return fn(order)
+	}
+
+	var current *models.Order
+	for {
+		rows, err := tx.Query(ctx, FetchStreamCursorQuery)
+		if err != nil {
+			return fmt.Errorf("fetch orders: %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+
+			order, item, err := scanStreamRow(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			if current == nil || current.OrderUID != order.OrderUID {
+				if current != nil {
+					if err := emit(current); err != nil {
+						rows.Close()
+						return err
+					}
+				}
+				current = order
+			}
+			if item != nil {
+				item.OrderUID = current.OrderUID
+				current.Items = append(current.Items, *item)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("iterate orders: %w", err)
+		}
+
+		if fetched == 0 {
+			break
+		}
+	}
+
+	if current != nil {
+		if err := emit(current); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// openCursor starts a read-only transaction on pool and declares the stream
+// cursor with query in it.
+func openCursor(ctx context.Context, pool *pgxpool.Pool, query string, args []any) (pgx.Tx, error) {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("start transaction: %w", err)
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, fmt.Errorf("declare cursor: %w", err)
+	}
+	return tx, nil
+}
+
+// EraseCustomer replaces the delivery name, phone, email and address of
+// every order of customerID with ErasedValue and records an audit entry per
+// order in the same transaction, attributed to the actor in ctx. Orders,
+// payments and items are kept. Orders erased before are skipped; the UIDs
+// of the orders erased now are returned.
+func (r *OrderRepository) EraseCustomer(ctx context.Context, customerID string) ([]string, error) {
+	start := time.Now()
+	defer func() {
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("erase_customer"), time.Since(start).Seconds())
+	}()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, EraseCustomerDeliveryQuery, customerID, ErasedValue)
+	if err != nil {
+		return nil, fmt.Errorf("erase deliveries: %w", err)
+	}
+	uids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("erase deliveries: %w", err)
+	}
+
+	// The previous values are personal data themselves, so only the new
+	// ones are recorded.
+	diff := make(map[string]models.FieldChange)
+	for _, field := range []string{"name", "phone", "email", "address"} {
+		diff["delivery."+field] = models.FieldChange{After: ErasedValue}
+	}
+	for _, uid := range uids {
+		if err := insertAuditTx(ctx, tx, models.AuditEntry{
+			Action:   ActionEraseCustomer,
+			OrderUID: &uid,
+			Diff:     diff,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	if r.recent != nil {
+		for _, uid := range uids {
+			r.recent.add(uid)
+		}
+	}
+	return uids, nil
+}
+
+// RekeyDeliveries encrypts the delivery PII of every order with the primary
+// key of the keyring, covering rows written in plaintext before encryption
+// was enabled and rows encrypted with an older key. It works in
+// transactions of batchSize rows and returns the number of rewritten rows.
+func (r *OrderRepository) RekeyDeliveries(ctx context.Context, batchSize int) (int, error) {
+	if r.keyring == nil {
+		return 0, errors.New("no keyring configured")
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.Observe(ctx, metrics.DBQueryDuration.WithLabelValues("rekey_deliveries"), time.Since(start).Seconds())
+	}()
+
+	rekeyed := 0
+	after := "00000000-0000-0000-0000-000000000000"
+	for {
+		n, last, err := r.rekeyBatch(ctx, after, batchSize)
+		rekeyed += n
+		if err != nil {
+			return rekeyed, err
+		}
+		if last == "" {
+			return rekeyed, nil
+		}
+		after = last
+	}
+}
+
+// rekeyBatch re-encrypts the deliveries of up to batchSize orders following
+// after and returns how many it rewrote and the last order_uid it saw,
+// which is empty once there are no more rows.
+func (r *OrderRepository) rekeyBatch(ctx context.Context, after string, batchSize int) (int, string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, "", fmt.Errorf("start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, SelectDeliveryPIIQuery, after, batchSize)
+	if err != nil {
+		return 0, "", fmt.Errorf("select deliveries: %w", err)
+	}
+	var batch []models.Delivery
+	for rows.Next() {
+		var d models.Delivery
+		if err := rows.Scan(&d.OrderUID, &d.Name, &d.Phone, &d.Email, &d.Address); err != nil {
+			rows.Close()
+			return 0, "", fmt.Errorf("scan delivery: %w", err)
+		}
+		batch = append(batch, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("iterate deliveries: %w", err)
+	}
+	if len(batch) == 0 {
+		return 0, "", nil
+	}
+
+	rekeyed := 0
+	for _, d := range batch {
+		if r.keyring.DeliveryCurrent(d) {
+			continue
+		}
+		if err := r.keyring.OpenDelivery(d.OrderUID, &d); err != nil {
+			return 0, "", fmt.Errorf("order %s: %w", d.OrderUID, err)
+		}
+		sealed, err := r.keyring.SealDelivery(d.OrderUID, d)
+		if err != nil {
+			return 0, "", fmt.Errorf("order %s: %w", d.OrderUID, err)
+		}
+		if _, err := tx.Exec(ctx, UpdateDeliveryPIIQuery,
+			d.OrderUID, sealed.Name, sealed.Phone, sealed.Email, sealed.Address); err != nil {
+			return 0, "", fmt.Errorf("update delivery %s: %w", d.OrderUID, err)
+		}
+		// Only the ciphertext changed, so there is no diff to record.
+		if err := insertAuditTx(ctx, tx, models.AuditEntry{
+			Action:   ActionRekeyDelivery,
+			OrderUID: &d.OrderUID,
+		}); err != nil {
+			return 0, "", err
+		}
+		rekeyed++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, "", fmt.Errorf("commit transaction: %w", err)
+	}
+	return rekeyed, batch[len(batch)-1].OrderUID, nil
+}
+
+func scanStreamRow(rows pgx.Rows) (*models.Order, *models.Item, error) {
+	order := &models.Order{}
+
+	var (
+		chrtID, nmID                        *int64
+		price, sale, totalPrice, status     *int
+		trackNumber, rid, name, size, brand *string
+	)
+
+	if err := rows.Scan(
+		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale,
+		&order.InternalSignature, &order.CustomerID, &order.DeliveryService,
+		&order.ShardKey, &order.SmID, &order.DateCreated, &order.OofShard,
+		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip,
+		&order.Delivery.City, &order.Delivery.Address, &order.Delivery.Region,
+		&order.Delivery.Email,
+		&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency,
+		&order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDt,
+		&order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal,
+		&order.Payment.CustomFee,
+		&chrtID, &trackNumber, &price, &rid, &name, &sale, &size,
+		&totalPrice, &nmID, &brand, &status,
+	); err != nil {
+		return nil, nil, fmt.Errorf("scan order row: %w", err)
+	}
+
+	order.Delivery.OrderUID = order.OrderUID
+	order.Payment.OrderUID = order.OrderUID
+
+	if chrtID == nil {
+		return order, nil, nil
+	}
+
+	return order, &models.Item{
+		ChrtID:      *chrtID,
+		TrackNumber: *trackNumber,
+		Price:       *price,
+		RID:         *rid,
+		Name:        *name,
+		Sale:        *sale,
+		Size:        *size,
+		TotalPrice:  *totalPrice,
+		NmID:        *nmID,
+		Brand:       *brand,
+		Status:      *status,
+	}, nil
+}
+
 func mapInsertError(err error, operation string) error {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -16,9 +16,9 @@ INSERT INTO payment (order_uid, transaction, request_id, currency, provider, amo
 VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`
 
 	InsertItemQuery = `
-INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, sale,
+INSERT INTO items (order_uid, date_created, chrt_id, track_number, price, rid, name, sale,
                    size, total_price, nm_id, brand, status)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)`
 
 	GetOrderWithJoinsQuery = `
 SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
@@ -35,7 +35,7 @@ WHERE o.order_uid = $1`
 SELECT chrt_id, track_number, price, rid, name, sale, size,
        total_price, nm_id, brand, status
 FROM items
-WHERE order_uid = $1`
+WHERE order_uid = $1 AND date_created = $2`
 
 	GetAllOrdersWithJoinsQuery = `
 SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
@@ -52,4 +52,52 @@ SELECT order_uid, chrt_id, track_number, price, rid, name, sale, size,
        total_price, nm_id, brand, status
 FROM items
 ORDER BY order_uid`
+
+	StreamOrdersSelect = `
+SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+       o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+       d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+       p.transaction, p.request_id, p.currency, p.provider, p.amount,
+       p.payment_dt, p.bank, p.delivery_cost, p.goods_total, p.custom_fee,
+       i.chrt_id, i.track_number, i.price, i.rid, i.name, i.sale, i.size,
+       i.total_price, i.nm_id, i.brand, i.status
+FROM orders o
+LEFT JOIN delivery d ON o.order_uid = d.order_uid
+LEFT JOIN payment p ON o.order_uid = p.order_uid
+LEFT JOIN items i ON o.order_uid = i.order_uid AND o.date_created = i.date_created`
+
+	StreamOrdersOrderBy = `
+ORDER BY o.date_created, o.order_uid, i.id`
+
+	DeclareStreamCursorQuery = `DECLARE orders_stream NO SCROLL CURSOR FOR `
+
+	FetchStreamCursorQuery = `FETCH 500 FROM orders_stream`
+
+	EraseCustomerDeliveryQuery = `
+UPDATE delivery d
+SET name = $2, phone = $2, email = $2, address = $2, erased_at = now()
+FROM orders o
+WHERE o.order_uid = d.order_uid AND o.customer_id = $1 AND d.erased_at IS NULL
+RETURNING d.order_uid::text`
+
+	InsertAuditQuery = `
+INSERT INTO audit_log (actor, source, action, order_uid, diff)
+VALUES ($1,$2,$3,$4,$5::jsonb)`
+
+	// ListAuditQuery is completed with the conditions of an AuditFilter.
+	ListAuditQuery = `
+SELECT id, created_at, actor, source, action, order_uid::text, diff
+FROM audit_log`
+
+	SelectDeliveryPIIQuery = `
+SELECT order_uid, name, phone, email, address
+FROM delivery
+WHERE order_uid > $1
+ORDER BY order_uid
+LIMIT $2
+FOR UPDATE`
+
+	UpdateDeliveryPIIQuery = `
+UPDATE delivery SET name = $2, phone = $3, email = $4, address = $5
+WHERE order_uid = $1`
 )
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReadRouter picks the pool for read-only queries; db.Cluster implements it.
+type ReadRouter interface {
+	Primary() *pgxpool.Pool
+	Reader() *pgxpool.Pool
+	MarkUnhealthy(pool *pgxpool.Pool)
+	MaxLag() time.Duration
+}
+
+// recentWrites remembers the orders this instance wrote during the last
+// window. Replicas may lag by up to that long, so reads of those orders go
+// to the primary to keep read-your-writes consistency.
+type recentWrites struct {
+	mu        sync.Mutex
+	window    time.Duration
+	written   map[string]time.Time
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func newRecentWrites(window time.Duration) *recentWrites {
+	return &recentWrites{
+		window:  window,
+		written: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (w *recentWrites) add(uids ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	for _, uid := range uids {
+		w.written[uid] = now
+	}
+
+	if now.Sub(w.lastPrune) > w.window {
+		for uid, at := range w.written {
+			if now.Sub(at) > w.window {
+				delete(w.written, uid)
+			}
+		}
+		w.lastPrune = now
+	}
+}
+
+func (w *recentWrites) contains(uid string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	at, ok := w.written[uid]
+	return ok && w.now().Sub(at) <= w.window
+}
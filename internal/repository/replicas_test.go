@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecentWrites(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	w := newRecentWrites(5 * time.Second)
+	w.now = func() time.Time { return now }
+
+	w.add("a")
+	now = now.Add(3 * time.Second)
+	w.add("b")
+
+	if !w.contains("a") || !w.contains("b") {
+		t.Fatal("expected both orders within the window")
+	}
+	if w.contains("c") {
+		t.Fatal("unexpected order c")
+	}
+
+	now = now.Add(3 * time.Second)
+	if w.contains("a") {
+		t.Error("order a should have left the window")
+	}
+	if !w.contains("b") {
+		t.Error("order b should still be within the window")
+	}
+
+	now = now.Add(10 * time.Second)
+	w.add("c")
+	if _, ok := w.written["a"]; ok {
+		t.Error("expired entries should be pruned on add")
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"container/list"
 	"sync"
+	"time"
 
 	"github.com/sonni-a/wb-service/internal/metrics"
 	"github.com/sonni-a/wb-service/internal/models"
@@ -12,40 +13,102 @@ type Cache interface {
 	Get(key string) (*models.Order, bool)
 	Set(key string, value *models.Order)
 	Delete(key string)
+	// DeleteFunc removes the orders for which del returns true and returns
+	// how many it removed.
+	DeleteFunc(del func(*models.Order) bool) int
 	Clear()
+	// Len is the number of orders held, including expired ones that have
+	// not been evicted yet.
+	Len() int
+	Stats() CacheStats
 }
 
+// CacheStats describes what a cache holds and how well it serves reads.
+type CacheStats struct {
+	Size    int `json:"size"`
+	MaxSize int `json:"max_size"`
+	// TTLSeconds is 0 when entries never expire.
+	TTLSeconds float64 `json:"ttl_seconds"`
+	// Hits and Misses count reads since the cache was created; reads of
+	// expired entries are misses.
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// HitRatio is Hits / (Hits + Misses), 0 before the first read.
+	HitRatio float64 `json:"hit_ratio"`
+	// OldestEntryAgeSeconds is the time since the longest-held entry was
+	// stored, 0 when the cache is empty.
+	OldestEntryAgeSeconds float64 `json:"oldest_entry_age_seconds"`
+}
+
+// MemoryCache is an LRU cache. With a TTL set, entries older than the TTL
+// are treated as missing.
 type MemoryCache struct {
 	mu      sync.Mutex
 	items   map[string]*list.Element
 	order   *list.List
 	maxSize int
+	ttl     time.Duration
+	now     func() time.Time
+
+	hits   uint64
+	misses uint64
 }
 
 type cacheEntry struct {
-	key   string
-	value *models.Order
+	key      string
+	value    *models.Order
+	storedAt time.Time
 }
 
-func NewMemoryCache(maxSize int) Cache {
+// NewMemoryCache creates a cache of up to maxSize orders; ttl 0 means
+// entries never expire.
+func NewMemoryCache(maxSize int, ttl time.Duration) *MemoryCache {
 	return &MemoryCache{
 		items:   make(map[string]*list.Element),
 		order:   list.New(),
 		maxSize: maxSize,
+		ttl:     ttl,
+		now:     time.Now,
 	}
 }
 
+// Resize changes the maximum size, evicting the least recently used
+// entries if the cache is now over it.
+func (c *MemoryCache) Resize(maxSize int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxSize = maxSize
+	for c.order.Len() > c.maxSize {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// SetTTL changes the TTL; it applies to entries already in the cache too.
+func (c *MemoryCache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
+}
+
 func (c *MemoryCache) Get(key string) (*models.Order, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if el, ok := c.items[key]; ok {
-		metrics.CacheHitsTotal.Inc()
-		c.order.MoveToFront(el)
-		return el.Value.(*cacheEntry).value, true
+		entry := el.Value.(*cacheEntry)
+		if c.ttl <= 0 || c.now().Sub(entry.storedAt) < c.ttl {
+			metrics.CacheHitsTotal.Inc()
+			c.hits++
+			c.order.MoveToFront(el)
+			return entry.value, true
+		}
+		c.removeElement(el)
 	}
 
 	metrics.CacheMissesTotal.Inc()
+	c.misses++
 	return nil, false
 }
 
@@ -54,22 +117,24 @@ func (c *MemoryCache) Set(key string, value *models.Order) {
 	defer c.mu.Unlock()
 
 	if el, ok := c.items[key]; ok {
-		el.Value.(*cacheEntry).value = value
+		entry := el.Value.(*cacheEntry)
+		entry.value = value
+		entry.storedAt = c.now()
 		c.order.MoveToFront(el)
 		return
 	}
 
-	entry := &cacheEntry{key, value}
+	entry := &cacheEntry{key: key, value: value, storedAt: c.now()}
 	el := c.order.PushFront(entry)
 	c.items[key] = el
 
 	if c.order.Len() > c.maxSize {
 		oldest := c.order.Back()
 		if oldest != nil {
-			c.order.Remove(oldest)
-			delete(c.items, oldest.Value.(*cacheEntry).key)
+			c.removeElement(oldest)
 		}
 	}
+	metrics.CacheSize.Set(float64(c.order.Len()))
 }
 
 func (c *MemoryCache) Delete(key string) {
@@ -77,15 +142,73 @@ func (c *MemoryCache) Delete(key string) {
 	defer c.mu.Unlock()
 
 	if el, ok := c.items[key]; ok {
-		c.order.Remove(el)
-		delete(c.items, key)
+		c.removeElement(el)
 	}
 }
 
+func (c *MemoryCache) DeleteFunc(del func(*models.Order) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		if del(el.Value.(*cacheEntry).value) {
+			c.removeElement(el)
+			n++
+		}
+		el = next
+	}
+	return n
+}
+
+func (c *MemoryCache) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry).key)
+	metrics.CacheSize.Set(float64(c.order.Len()))
+}
+
 func (c *MemoryCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.items = make(map[string]*list.Element)
 	c.order.Init()
+	metrics.CacheSize.Set(0)
+}
+
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Stats scans every entry to find the oldest one, as the LRU order follows
+// reads rather than writes.
+func (c *MemoryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		Size:       c.order.Len(),
+		MaxSize:    c.maxSize,
+		TTLSeconds: c.ttl.Seconds(),
+		Hits:       c.hits,
+		Misses:     c.misses,
+	}
+	if reads := c.hits + c.misses; reads > 0 {
+		stats.HitRatio = float64(c.hits) / float64(reads)
+	}
+
+	var oldest time.Time
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		if storedAt := el.Value.(*cacheEntry).storedAt; oldest.IsZero() || storedAt.Before(oldest) {
+			oldest = storedAt
+		}
+	}
+	if !oldest.IsZero() {
+		stats.OldestEntryAgeSeconds = c.now().Sub(oldest).Seconds()
+	}
+	return stats
 }
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sonni-a/wb-service/internal/metrics"
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+func TestMemoryCache_TTL(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewMemoryCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", &models.Order{OrderUID: "a"})
+	now = now.Add(30 * time.Second)
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("entry should still be cached")
+	}
+
+	now = now.Add(31 * time.Second)
+	if _, ok := cache.Get("a"); ok {
+		t.Fatal("entry should have expired")
+	}
+	if _, ok := cache.items["a"]; ok {
+		t.Error("expired entry should be removed")
+	}
+
+	cache.Set("b", &models.Order{OrderUID: "b"})
+	now = now.Add(2 * time.Minute)
+	cache.SetTTL(0)
+	if _, ok := cache.Get("b"); !ok {
+		t.Error("without a TTL entries should not expire")
+	}
+}
+
+func TestMemoryCache_Resize(t *testing.T) {
+	cache := NewMemoryCache(3, 0)
+	for _, uid := range []string{"a", "b", "c"} {
+		cache.Set(uid, &models.Order{OrderUID: uid})
+	}
+	cache.Get("a")
+
+	cache.Resize(2)
+	if _, ok := cache.Get("b"); ok {
+		t.Error("least recently used entry b should be evicted")
+	}
+	for _, uid := range []string{"a", "c"} {
+		if _, ok := cache.Get(uid); !ok {
+			t.Errorf("entry %s should be kept", uid)
+		}
+	}
+
+	cache.Resize(5)
+	cache.Set("d", &models.Order{OrderUID: "d"})
+	cache.Set("e", &models.Order{OrderUID: "e"})
+	if got := cache.order.Len(); got != 4 {
+		t.Errorf("len = %d, want 4", got)
+	}
+}
+
+func TestMemoryCache_SizeGauge(t *testing.T) {
+	cache := NewMemoryCache(2, 0)
+	cache.Set("a", &models.Order{OrderUID: "a"})
+	cache.Set("b", &models.Order{OrderUID: "b"})
+	cache.Set("c", &models.Order{OrderUID: "c"})
+	if got := testutil.ToFloat64(metrics.CacheSize); got != 2 {
+		t.Errorf("cache_size = %v, want 2", got)
+	}
+
+	cache.Delete("c")
+	if got := testutil.ToFloat64(metrics.CacheSize); got != 1 {
+		t.Errorf("cache_size = %v, want 1", got)
+	}
+
+	cache.Clear()
+	if got := testutil.ToFloat64(metrics.CacheSize); got != 0 {
+		t.Errorf("cache_size = %v, want 0", got)
+	}
+}
+
+func TestMemoryCache_Stats(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewMemoryCache(10, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	if stats := cache.Stats(); stats != (CacheStats{MaxSize: 10, TTLSeconds: 3600}) {
+		t.Errorf("empty cache stats = %+v", stats)
+	}
+
+	cache.Set("a", &models.Order{OrderUID: "a"})
+	now = now.Add(time.Minute)
+	cache.Set("b", &models.Order{OrderUID: "b"})
+	now = now.Add(time.Minute)
+	// Reading a moves it to the front of the LRU list without making it
+	// any younger.
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("missing")
+
+	stats := cache.Stats()
+	want := CacheStats{
+		Size:                  2,
+		MaxSize:               10,
+		TTLSeconds:            3600,
+		Hits:                  2,
+		Misses:                1,
+		HitRatio:              2.0 / 3,
+		OldestEntryAgeSeconds: 120,
+	}
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+}
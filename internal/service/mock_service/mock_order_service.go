@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: C:\Users\HONOR\Desktop\wb-service\internal\service\order_service.go
+// Source: internal/service/order_service.go
 
 // Package mock_service is a generated GoMock package.
 package mock_service
@@ -10,6 +10,8 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/sonni-a/wb-service/internal/models"
+	repository "github.com/sonni-a/wb-service/internal/repository"
+	service "github.com/sonni-a/wb-service/internal/service"
 )
 
 // MockOrderServiceInterface is a mock of OrderServiceInterface interface.
@@ -49,6 +51,21 @@ func (mr *MockOrderServiceInterfaceMockRecorder) CreateOrder(ctx, order interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).CreateOrder), ctx, order)
 }
 
+// EraseCustomer mocks base method.
+func (m *MockOrderServiceInterface) EraseCustomer(ctx context.Context, customerID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseCustomer", ctx, customerID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EraseCustomer indicates an expected call of EraseCustomer.
+func (mr *MockOrderServiceInterfaceMockRecorder) EraseCustomer(ctx, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseCustomer", reflect.TypeOf((*MockOrderServiceInterface)(nil).EraseCustomer), ctx, customerID)
+}
+
 // GetOrder mocks base method.
 func (m *MockOrderServiceInterface) GetOrder(ctx context.Context, orderUID string) (*models.Order, error) {
 	m.ctrl.T.Helper()
@@ -63,3 +80,108 @@ func (mr *MockOrderServiceInterfaceMockRecorder) GetOrder(ctx, orderUID interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).GetOrder), ctx, orderUID)
 }
+
+// InsertOrders mocks base method.
+func (m *MockOrderServiceInterface) InsertOrders(ctx context.Context, orders []*models.Order) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertOrders", ctx, orders)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertOrders indicates an expected call of InsertOrders.
+func (mr *MockOrderServiceInterfaceMockRecorder) InsertOrders(ctx, orders interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrders", reflect.TypeOf((*MockOrderServiceInterface)(nil).InsertOrders), ctx, orders)
+}
+
+// StreamOrders mocks base method.
+func (m *MockOrderServiceInterface) StreamOrders(ctx context.Context, filter repository.OrderFilter, fn func(*models.Order) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamOrders", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamOrders indicates an expected call of StreamOrders.
+func (mr *MockOrderServiceInterfaceMockRecorder) StreamOrders(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamOrders", reflect.TypeOf((*MockOrderServiceInterface)(nil).StreamOrders), ctx, filter, fn)
+}
+
+// MockCacheAdmin is a mock of CacheAdmin interface.
+type MockCacheAdmin struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheAdminMockRecorder
+}
+
+// MockCacheAdminMockRecorder is the mock recorder for MockCacheAdmin.
+type MockCacheAdminMockRecorder struct {
+	mock *MockCacheAdmin
+}
+
+// NewMockCacheAdmin creates a new mock instance.
+func NewMockCacheAdmin(ctrl *gomock.Controller) *MockCacheAdmin {
+	mock := &MockCacheAdmin{ctrl: ctrl}
+	mock.recorder = &MockCacheAdminMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheAdmin) EXPECT() *MockCacheAdminMockRecorder {
+	return m.recorder
+}
+
+// CacheStats mocks base method.
+func (m *MockCacheAdmin) CacheStats() service.CacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheStats")
+	ret0, _ := ret[0].(service.CacheStats)
+	return ret0
+}
+
+// CacheStats indicates an expected call of CacheStats.
+func (mr *MockCacheAdminMockRecorder) CacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheStats", reflect.TypeOf((*MockCacheAdmin)(nil).CacheStats))
+}
+
+// ClearCache mocks base method.
+func (m *MockCacheAdmin) ClearCache() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ClearCache")
+}
+
+// ClearCache indicates an expected call of ClearCache.
+func (mr *MockCacheAdminMockRecorder) ClearCache() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearCache", reflect.TypeOf((*MockCacheAdmin)(nil).ClearCache))
+}
+
+// EvictOrder mocks base method.
+func (m *MockCacheAdmin) EvictOrder(orderUID string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EvictOrder", orderUID)
+}
+
+// EvictOrder indicates an expected call of EvictOrder.
+func (mr *MockCacheAdminMockRecorder) EvictOrder(orderUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvictOrder", reflect.TypeOf((*MockCacheAdmin)(nil).EvictOrder), orderUID)
+}
+
+// LoadCache mocks base method.
+func (m *MockCacheAdmin) LoadCache(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadCache", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadCache indicates an expected call of LoadCache.
+func (mr *MockCacheAdminMockRecorder) LoadCache(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadCache", reflect.TypeOf((*MockCacheAdmin)(nil).LoadCache), ctx)
+}
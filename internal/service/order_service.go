@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/sonni-a/wb-service/internal/models"
 	"github.com/sonni-a/wb-service/internal/repository"
@@ -12,14 +15,31 @@ import (
 type OrderServiceInterface interface {
 	CreateOrder(ctx context.Context, order *models.Order) error
 	GetOrder(ctx context.Context, orderUID string) (*models.Order, error)
+	InsertOrders(ctx context.Context, orders []*models.Order) ([]error, error)
+	StreamOrders(ctx context.Context, filter repository.OrderFilter, fn func(*models.Order) error) error
+	EraseCustomer(ctx context.Context, customerID string) ([]string, error)
+}
+
+// CacheAdmin inspects and manages the order cache for operators.
+type CacheAdmin interface {
+	CacheStats() CacheStats
+	EvictOrder(orderUID string)
+	ClearCache()
+	LoadCache(ctx context.Context) (int, error)
 }
 
 type OrderService struct {
 	repo  repository.OrderRepo
 	cache Cache
+
+	// warmed is set once LoadCache has succeeded, see CheckCache.
+	warmed atomic.Bool
 }
 
-var _ OrderServiceInterface = (*OrderService)(nil)
+var (
+	_ OrderServiceInterface = (*OrderService)(nil)
+	_ CacheAdmin            = (*OrderService)(nil)
+)
 
 func NewOrderService(repo repository.OrderRepo, cache Cache) *OrderService {
 	return &OrderService{
@@ -45,8 +65,10 @@ func (s *OrderService) CreateOrder(ctx context.Context, order *models.Order) err
 
 func (s *OrderService) GetOrder(ctx context.Context, orderUID string) (*models.Order, error) {
 	if order, ok := s.cache.Get(orderUID); ok {
+		slog.DebugContext(ctx, "Order served from cache")
 		return order, nil
 	}
+	slog.DebugContext(ctx, "Order cache miss")
 
 	order, err := s.repo.GetOrder(ctx, orderUID)
 	if err != nil {
@@ -60,15 +82,87 @@ func (s *OrderService) GetOrder(ctx context.Context, orderUID string) (*models.O
 	return order, nil
 }
 
-func (s *OrderService) LoadCache(ctx context.Context) error {
+// InsertOrders stores a batch of orders without touching the cache and maps
+// per-order duplicate errors to ErrOrderAlreadyExists.
+func (s *OrderService) InsertOrders(ctx context.Context, orders []*models.Order) ([]error, error) {
+	results, err := s.repo.InsertOrders(ctx, orders)
+	if err != nil {
+		return nil, fmt.Errorf("insert orders: %w", err)
+	}
+
+	for i, err := range results {
+		if errors.Is(err, repository.ErrOrderAlreadyExists) {
+			results[i] = ErrOrderAlreadyExists
+		}
+	}
+	return results, nil
+}
+
+// StreamOrders bypasses the cache and reads orders straight from the
+// repository, which is what bulk consumers like exports want.
+func (s *OrderService) StreamOrders(ctx context.Context, filter repository.OrderFilter, fn func(*models.Order) error) error {
+	if err := s.repo.StreamOrders(ctx, filter, fn); err != nil {
+		return fmt.Errorf("stream orders: %w", err)
+	}
+	return nil
+}
+
+// EraseCustomer erases the delivery PII of every order of customerID, see
+// repository.OrderRepo.EraseCustomer, and evicts the erased orders from the
+// cache so that their old data is no longer served.
+func (s *OrderService) EraseCustomer(ctx context.Context, customerID string) ([]string, error) {
+	uids, err := s.repo.EraseCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("erase customer: %w", err)
+	}
+	for _, uid := range uids {
+		s.cache.Delete(uid)
+	}
+	return uids, nil
+}
+
+// LoadCache warms the cache with the orders in the repository, replacing
+// cached copies, and returns how many were loaded. Orders beyond the cache
+// size evict each other as usual.
+func (s *OrderService) LoadCache(ctx context.Context) (int, error) {
 	orders, err := s.repo.GetAllOrders(ctx)
 	if err != nil {
-		return fmt.Errorf("load cache: %w", err)
+		return 0, fmt.Errorf("load cache: %w", err)
 	}
 
 	for _, order := range orders {
 		s.cache.Set(order.OrderUID, order)
 	}
+	s.warmed.Store(true)
+
+	return len(orders), nil
+}
 
+// CheckCache is the readiness check of the cache: it fails until LoadCache
+// has succeeded once. Clearing the cache later does not make it fail.
+func (s *OrderService) CheckCache(context.Context) error {
+	if !s.warmed.Load() {
+		return errors.New("warm-up has not completed")
+	}
 	return nil
 }
+
+func (s *OrderService) CacheStats() CacheStats {
+	return s.cache.Stats()
+}
+
+// EvictOrder drops orderUID from the cache; the next read loads it from
+// the repository.
+func (s *OrderService) EvictOrder(orderUID string) {
+	s.cache.Delete(orderUID)
+}
+
+// EvictCreatedBefore drops the orders created before t from the cache, as
+// when partition retention has removed them from the repository.
+func (s *OrderService) EvictCreatedBefore(t time.Time) int {
+	return s.cache.DeleteFunc(func(o *models.Order) bool { return o.DateCreated.Before(t) })
+}
+
+func (s *OrderService) ClearCache() {
+	s.cache.Clear()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sonni-a/wb-service/internal/models"
@@ -16,7 +17,7 @@ func TestOrderService_GetOrder(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_repository.NewMockOrderRepo(ctrl)
-	cache := NewMemoryCache(2)
+	cache := NewMemoryCache(2, 0)
 
 	service := NewOrderService(mockRepo, cache)
 
@@ -47,7 +48,7 @@ func TestOrderService_CreateOrder(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_repository.NewMockOrderRepo(ctrl)
-	cache := NewMemoryCache(2)
+	cache := NewMemoryCache(2, 0)
 	service := NewOrderService(mockRepo, cache)
 
 	ctx := context.Background()
@@ -74,7 +75,7 @@ func TestOrderService_CreateOrder_Duplicate(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_repository.NewMockOrderRepo(ctrl)
-	cache := NewMemoryCache(2)
+	cache := NewMemoryCache(2, 0)
 	service := NewOrderService(mockRepo, cache)
 
 	ctx := context.Background()
@@ -99,7 +100,7 @@ func TestOrderService_CreateOrder_Duplicate(t *testing.T) {
 }
 
 func TestMemoryCache_Eviction(t *testing.T) {
-	cache := NewMemoryCache(2)
+	cache := NewMemoryCache(2, 0)
 
 	order1 := &models.Order{OrderUID: "1"}
 	order2 := &models.Order{OrderUID: "2"}
@@ -119,3 +120,118 @@ func TestMemoryCache_Eviction(t *testing.T) {
 		t.Fatalf("expected order3 to remain")
 	}
 }
+
+func TestOrderService_InsertOrders_MapsDuplicates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repository.NewMockOrderRepo(ctrl)
+	service := NewOrderService(mockRepo, NewMemoryCache(2, 0))
+
+	ctx := context.Background()
+	orders := []*models.Order{{OrderUID: "a"}, {OrderUID: "b"}}
+
+	mockRepo.EXPECT().InsertOrders(ctx, orders).Return([]error{nil, repository.ErrOrderAlreadyExists}, nil)
+
+	results, err := service.InsertOrders(ctx, orders)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0] != nil {
+		t.Fatalf("expected first order to be accepted, got %v", results[0])
+	}
+	if !errors.Is(results[1], ErrOrderAlreadyExists) {
+		t.Fatalf("expected ErrOrderAlreadyExists, got %v", results[1])
+	}
+}
+
+func TestOrderService_EraseCustomer_EvictsCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repository.NewMockOrderRepo(ctrl)
+	cache := NewMemoryCache(10, 0)
+	service := NewOrderService(mockRepo, cache)
+
+	ctx := context.Background()
+	for _, uid := range []string{"a", "b", "c"} {
+		cache.Set(uid, &models.Order{OrderUID: uid, CustomerID: "cust"})
+	}
+
+	mockRepo.EXPECT().EraseCustomer(ctx, "cust").Return([]string{"a", "b"}, nil)
+
+	uids, err := service.EraseCustomer(ctx, "cust")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uids) != 2 {
+		t.Fatalf("expected 2 erased orders, got %v", uids)
+	}
+	for uid, cached := range map[string]bool{"a": false, "b": false, "c": true} {
+		if _, ok := cache.Get(uid); ok != cached {
+			t.Errorf("order %s cached = %v, want %v", uid, ok, cached)
+		}
+	}
+}
+
+func TestOrderService_CacheAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repository.NewMockOrderRepo(ctrl)
+	cache := NewMemoryCache(10, 0)
+	service := NewOrderService(mockRepo, cache)
+
+	ctx := context.Background()
+	stale := &models.Order{OrderUID: "a", TrackNumber: "old"}
+	cache.Set("a", stale)
+
+	fresh := &models.Order{OrderUID: "a", TrackNumber: "new"}
+	mockRepo.EXPECT().GetAllOrders(ctx).Return([]*models.Order{fresh, {OrderUID: "b"}}, nil)
+
+	if err := service.CheckCache(ctx); err == nil {
+		t.Error("cache should not be ready before warm-up")
+	}
+	n, err := service.LoadCache(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.CheckCache(ctx); err != nil {
+		t.Errorf("cache should be ready after warm-up: %v", err)
+	}
+	if n != 2 || service.CacheStats().Size != 2 {
+		t.Fatalf("loaded %d orders, cache holds %d; want 2", n, service.CacheStats().Size)
+	}
+	if got, _ := cache.Get("a"); got != fresh {
+		t.Errorf("cached order a = %+v, want the reloaded one", got)
+	}
+
+	service.EvictOrder("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("order a should be evicted")
+	}
+
+	service.ClearCache()
+	if cache.Len() != 0 {
+		t.Errorf("cache holds %d orders after clear", cache.Len())
+	}
+}
+
+func TestOrderService_EvictCreatedBefore(t *testing.T) {
+	cache := NewMemoryCache(10, 0)
+	service := NewOrderService(nil, cache)
+
+	cutoff := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	cache.Set("old", &models.Order{OrderUID: "old", DateCreated: cutoff.Add(-time.Second)})
+	cache.Set("kept", &models.Order{OrderUID: "kept", DateCreated: cutoff})
+
+	if n := service.EvictCreatedBefore(cutoff); n != 1 {
+		t.Errorf("evicted %d orders, want 1", n)
+	}
+	if _, ok := cache.Get("old"); ok {
+		t.Error("order created before the cutoff should be evicted")
+	}
+	if _, ok := cache.Get("kept"); !ok {
+		t.Error("order created at the cutoff should stay cached")
+	}
+}
@@ -2,38 +2,49 @@ package shutdown
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 )
 
-func GracefulShutdown(server *http.Server, serverErr <-chan error, cancelFuncs ...func()) {
+// GracefulShutdown waits for SIGINT, SIGTERM or a server error, then shuts
+// all servers down in parallel within timeout and calls cancelFuncs.
+func GracefulShutdown(servers []*http.Server, serverErr <-chan error, timeout time.Duration, cancelFuncs ...func()) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	defer signal.Stop(quit)
 
 	select {
 	case <-quit:
-		log.Println("Shutting down server...")
+		slog.Info("Shutting down server...")
 	case err := <-serverErr:
 		if err != nil {
-			log.Printf("HTTP server stopped unexpectedly: %v", err)
+			slog.Error("HTTP server stopped unexpectedly", "error", err)
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				slog.Error("Server shutdown error", "addr", server.Addr, "error", err)
+			}
+		}()
 	}
+	wg.Wait()
 
 	for _, cancelFunc := range cancelFuncs {
 		cancelFunc()
 	}
 
-	log.Println("Server exited gracefully")
+	slog.Info("Server exited gracefully")
 }
@@ -0,0 +1,259 @@
+// Package strictjson decodes JSON with checks that encoding/json skips:
+// unknown fields, nulls in non-nullable fields, type mismatches and trailing
+// data are all rejected, and every problem is reported with its JSON path.
+package strictjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a single problem at a JSON path such as $.items[0].price.
+type FieldError struct {
+	Path string
+	Msg  string
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Msg
+}
+
+// Errors lists every problem found in a document.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Unmarshal checks data against the Go type of v and then decodes it into v.
+// The returned error is either a syntax error or Errors.
+func Unmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("malformed JSON: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("malformed JSON: unexpected data after top-level value")
+	}
+
+	var errs Errors
+	check(doc, reflect.TypeOf(v).Elem(), "$", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func check(v any, t reflect.Type, path string, errs *Errors) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, &FieldError{Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		default:
+			fail("must not be null")
+		}
+		return
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		raw, _ := json.Marshal(v)
+		if err := reflect.New(t).Interface().(json.Unmarshaler).UnmarshalJSON(raw); err != nil {
+			fail("invalid %s: %v", t.Name(), err)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("expected object, got %s", kindOf(v))
+			return
+		}
+		checkStruct(obj, t, path, errs)
+
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			fail("expected array, got %s", kindOf(v))
+			return
+		}
+		for i, el := range arr {
+			check(el, t.Elem(), path+"["+strconv.Itoa(i)+"]", errs)
+		}
+
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("expected object, got %s", kindOf(v))
+			return
+		}
+		for key, el := range obj {
+			check(el, t.Elem(), path+"["+strconv.Quote(key)+"]", errs)
+		}
+
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			fail("expected string, got %s", kindOf(v))
+		}
+
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			fail("expected boolean, got %s", kindOf(v))
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := v.(json.Number)
+		if !ok {
+			fail("expected integer, got %s", kindOf(v))
+			return
+		}
+		if _, err := strconv.ParseInt(n.String(), 10, t.Bits()); err != nil {
+			fail("expected %d-bit integer, got %s", t.Bits(), n)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := v.(json.Number)
+		if !ok {
+			fail("expected non-negative integer, got %s", kindOf(v))
+			return
+		}
+		if _, err := strconv.ParseUint(n.String(), 10, t.Bits()); err != nil {
+			fail("expected %d-bit non-negative integer, got %s", t.Bits(), n)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		n, ok := v.(json.Number)
+		if !ok {
+			fail("expected number, got %s", kindOf(v))
+			return
+		}
+		if _, err := strconv.ParseFloat(n.String(), t.Bits()); err != nil {
+			fail("number %s out of range", n)
+		}
+	}
+}
+
+func checkStruct(obj map[string]any, t reflect.Type, path string, errs *Errors) {
+	fields := jsonFields(t)
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	// Map iteration order is random; keep error output stable.
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			msg := "unknown field"
+			if hint := closestField(key, fields); hint != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", hint)
+			}
+			*errs = append(*errs, &FieldError{Path: path + "." + key, Msg: msg})
+			continue
+		}
+		check(obj[key], field.Type, path+"."+key, errs)
+	}
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// closestField suggests a known field for a misspelled key: either a
+// case-insensitive match or one within two edits.
+func closestField(key string, fields map[string]reflect.StructField) string {
+	best, bestDist := "", 3
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func kindOf(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
@@ -0,0 +1,77 @@
+package strictjson
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+func TestUnmarshal_ValidOrder(t *testing.T) {
+	sig := "sig"
+	want := models.Order{
+		OrderUID:          "abc",
+		InternalSignature: &sig,
+		SmID:              7,
+		DateCreated:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Payment:           models.Payment{PaymentDt: 1700000000},
+		Items:             []models.Item{{ChrtID: 1, Price: 10}},
+	}
+	data, _ := json.Marshal(want)
+
+	var got models.Order
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.OrderUID != "abc" || *got.InternalSignature != "sig" || !got.DateCreated.Equal(want.DateCreated) {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestUnmarshal_ReportsPaths(t *testing.T) {
+	data := []byte(`{
+		"order_uid": "abc",
+		"delivery_servise": "meest",
+		"sm_id": "7",
+		"date_created": "yesterday",
+		"payment": {"amount": 1.5, "request_id": null},
+		"items": [{"price": 10}, {"price": "10", "Brand": "x"}],
+		"oof_shard": null
+	}`)
+
+	var order models.Order
+	err := Unmarshal(data, &order)
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+
+	want := []string{
+		`$.date_created: invalid Time`,
+		`$.delivery_servise: unknown field (did you mean "delivery_service"?)`,
+		`$.items[1].Brand: unknown field (did you mean "brand"?)`,
+		`$.items[1].price: expected integer, got string`,
+		`$.oof_shard: must not be null`,
+		`$.payment.amount: expected 64-bit integer, got 1.5`,
+		`$.sm_id: expected integer, got string`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(errs), err)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(errs[i].Error(), prefix) {
+			t.Errorf("error %d: expected prefix %q, got %q", i, prefix, errs[i].Error())
+		}
+	}
+}
+
+func TestUnmarshal_RejectsTrailingData(t *testing.T) {
+	var order models.Order
+	if err := Unmarshal([]byte(`{"order_uid":"a"} {"order_uid":"b"}`), &order); err == nil {
+		t.Fatalf("expected error for trailing data")
+	}
+}
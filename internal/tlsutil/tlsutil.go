@@ -0,0 +1,173 @@
+// Package tlsutil builds TLS configurations from PEM files: a server
+// configuration whose certificate is reloaded when the files change, with
+// optional client certificate verification, and client configurations for
+// outgoing connections.
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/auth"
+)
+
+// CertReloader serves a certificate and key pair from files and picks up
+// new files, e.g. renewed by cert-manager or certbot, without a restart.
+type CertReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex // serializes reloads
+	cert    atomic.Pointer[tls.Certificate]
+	modTime time.Time
+}
+
+// NewCertReloader loads the certificate and key pair from certFile and
+// keyFile.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the files again. If they do not hold a valid pair, the
+// current certificate is kept.
+func (r *CertReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reload(r.latestModTime())
+}
+
+func (r *CertReloader) reload(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	r.cert.Store(&cert)
+	r.modTime = modTime
+	return nil
+}
+
+// latestModTime is the modification time of the newer of the two files,
+// or the zero time if either cannot be read.
+func (r *CertReloader) latestModTime() time.Time {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest
+}
+
+// Watch reloads the certificate every interval if either file changed,
+// until ctx is done. A renewal that writes the two files one after the
+// other may be seen half done; the mismatched pair fails to load and is
+// retried at the next check.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			modTime := r.latestModTime()
+			if !modTime.IsZero() && !modTime.Equal(r.modTime) {
+				if err := r.reload(modTime); err != nil {
+					slog.ErrorContext(ctx, "TLS certificate reload failed, keeping the current one", "error", err)
+				} else {
+					slog.InfoContext(ctx, "TLS certificate reloaded", "cert_file", r.certFile)
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// LoadCertPool reads a PEM bundle of CA certificates.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA file %s: no PEM certificates", file)
+	}
+	return pool, nil
+}
+
+// ServerConfig serves the certificate of certs. With clientCAs set,
+// clients may present a certificate, which is then verified against
+// clientCAs; RequireClientCert enforces it per route.
+func ServerConfig(certs *CertReloader, clientCAs *x509.CertPool) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+	if clientCAs != nil {
+		cfg.ClientCAs = clientCAs
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg
+}
+
+// ClientConfig builds the configuration of outgoing connections. caFile
+// replaces the system roots when set; certFile and keyFile, when set, are
+// the client certificate presented to servers that require one.
+func ClientConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := LoadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load TLS client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// RequireClientCert rejects requests that did not come over TLS with a
+// client certificate verified by the server's ClientCAs, with 403 in the
+// format of auth.Error.
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			slog.InfoContext(r.Context(), "Request without a verified client certificate rejected")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(auth.Error{Error: "client_certificate_required", Message: "a client certificate signed by a trusted CA is required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,172 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/tlsutil/tlstest"
+)
+
+// newServer starts a TLS server with the certificate from certs that
+// answers 200 on /public and requires a client certificate on /admin.
+func newServer(t *testing.T, certs *CertReloader, clientCA string) *httptest.Server {
+	t.Helper()
+	pool, err := LoadCertPool(clientCA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mux := http.NewServeMux()
+	mux.Handle("/public", ok)
+	mux.Handle("/admin", RequireClientCert(ok))
+
+	// StartTLS would add its own certificate, so the listener is wrapped
+	// by hand.
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener = tls.NewListener(srv.Listener, ServerConfig(certs, pool))
+	srv.Start()
+	srv.URL = "https://" + srv.Listener.Addr().String()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func client(t *testing.T, caFile string, cert *tlstest.Cert) *http.Client {
+	t.Helper()
+	var certFile, keyFile string
+	if cert != nil {
+		certFile, keyFile = cert.CertFile, cert.KeyFile
+	}
+	cfg, err := ClientConfig(caFile, certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+}
+
+func get(t *testing.T, c *http.Client, url string) (int, error) {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestServerConfig_ClientCertificates(t *testing.T) {
+	serverCA := tlstest.NewCA(t, "server-ca")
+	clientCA := tlstest.NewCA(t, "client-ca")
+	serverCert := serverCA.Server(t, "server")
+
+	certs, err := NewCertReloader(serverCert.CertFile, serverCert.KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newServer(t, certs, clientCA.CertFile)
+
+	trusted := clientCA.Client(t, "ops")
+	untrusted := tlstest.NewCA(t, "other-ca").Client(t, "intruder")
+
+	tests := []struct {
+		name string
+		cert *tlstest.Cert
+		path string
+		want int
+	}{
+		{"public without certificate", nil, "/public", http.StatusOK},
+		{"admin without certificate", nil, "/admin", http.StatusForbidden},
+		{"admin with trusted certificate", &trusted, "/admin", http.StatusOK},
+		// Go clients do not even send a certificate the server's CAs do
+		// not accept.
+		{"admin with untrusted certificate", &untrusted, "/admin", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := get(t, client(t, serverCA.CertFile, tt.cert), srv.URL+tt.path)
+			if err != nil || status != tt.want {
+				t.Errorf("GET %s = %d, %v; want %d", tt.path, status, err, tt.want)
+			}
+		})
+	}
+
+	// Clients that do not trust the server CA refuse the connection.
+	if _, err := get(t, client(t, clientCA.CertFile, nil), srv.URL+"/public"); err == nil {
+		t.Error("client accepted a server certificate from an untrusted CA")
+	}
+}
+
+func TestCertReloader_Watch(t *testing.T) {
+	oldCA, newCA := tlstest.NewCA(t, "old-ca"), tlstest.NewCA(t, "new-ca")
+	cert := oldCA.Server(t, "server")
+
+	certs, err := NewCertReloader(cert.CertFile, cert.KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newServer(t, certs, oldCA.CertFile)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go certs.Watch(ctx, 10*time.Millisecond)
+
+	// Broken files are ignored and the current certificate is kept.
+	if err := os.WriteFile(cert.CertFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := get(t, client(t, oldCA.CertFile, nil), srv.URL+"/public"); err != nil {
+		t.Fatalf("certificate lost after a failed reload: %v", err)
+	}
+
+	renewed := newCA.Server(t, "server")
+	for _, f := range [][2]string{{renewed.CertFile, cert.CertFile}, {renewed.KeyFile, cert.KeyFile}} {
+		data, err := os.ReadFile(f[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f[1], data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Make sure the change is seen even where file times are coarse.
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(cert.KeyFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	newClient := client(t, newCA.CertFile, nil)
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		// Fresh connections only: a kept-alive one still uses the old
+		// certificate.
+		newClient.CloseIdleConnections()
+		if _, err := get(t, newClient, srv.URL+"/public"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("renewed certificate not served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientConfig_Errors(t *testing.T) {
+	ca := tlstest.NewCA(t, "ca")
+	cert := ca.Client(t, "client")
+
+	if _, err := ClientConfig(cert.KeyFile, "", ""); err == nil {
+		t.Error("a key file was accepted as a CA bundle")
+	}
+	if _, err := ClientConfig("", cert.CertFile, ""); err == nil {
+		t.Error("a client certificate without a key was accepted")
+	}
+	cfg, err := ClientConfig("", cert.CertFile, cert.KeyFile)
+	if err != nil || len(cfg.Certificates) != 1 || cfg.RootCAs != nil || cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("ClientConfig = %+v, %v", cfg, err)
+	}
+}
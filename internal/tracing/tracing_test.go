@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup_Stdout(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, ServiceName: "test-service", Stdout: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{`"Name":"work"`, "test-service"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("exported spans do not contain %s:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Error("expected an error for an unknown exporter")
+	}
+}
+
+func TestRoutes_NamesSpanAfterPattern(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /order/{uid}", func(w http.ResponseWriter, r *http.Request) {})
+	h := Routes(mux)
+
+	ctx, span := tracer.Start(context.Background(), "http.request")
+	req := httptest.NewRequest(http.MethodGet, "/order/abc", nil).WithContext(ctx)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	span.End()
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if got := spans[0].Name(); got != "GET /order/{uid}" {
+		t.Errorf("span name = %q", got)
+	}
+}
@@ -33,6 +33,9 @@ func ValidateOrder(o *models.Order) error {
 	if strings.TrimSpace(o.Locale) == "" {
 		return errors.New("locale cannot be empty")
 	}
+	if err := validateOptional("internal_signature", o.InternalSignature); err != nil {
+		return err
+	}
 	if strings.TrimSpace(o.CustomerID) == "" {
 		return errors.New("customer_id cannot be empty")
 	}
@@ -93,10 +96,10 @@ func validateDelivery(d *models.Delivery) error {
 	}
 
 	if !phoneRegex.MatchString(d.Phone) {
-		return fmt.Errorf("invalid phone format: %s", d.Phone)
+		return errors.New("invalid phone format")
 	}
 	if !emailRegex.MatchString(d.Email) {
-		return fmt.Errorf("invalid email format: %s", d.Email)
+		return errors.New("invalid email format")
 	}
 	if !zipRegex.MatchString(d.Zip) {
 		return fmt.Errorf("invalid zip code: %s", d.Zip)
@@ -109,6 +112,9 @@ func validatePayment(p *models.Payment) error {
 	if strings.TrimSpace(p.Transaction) == "" {
 		return errors.New("transaction cannot be empty")
 	}
+	if err := validateOptional("request_id", p.RequestID); err != nil {
+		return err
+	}
 
 	if !currencyRegex.MatchString(p.Currency) {
 		return fmt.Errorf("invalid currency: %s", p.Currency)
@@ -184,3 +190,13 @@ func validateItem(i *models.Item) error {
 	}
 	return nil
 }
+
+// validateOptional checks a nullable field. nil (not provided) and "" (known
+// to be empty) are both valid and stored as NULL and "" respectively, but a
+// whitespace-only value is neither and is rejected.
+func validateOptional(name string, v *string) error {
+	if v != nil && *v != "" && strings.TrimSpace(*v) == "" {
+		return fmt.Errorf("%s must be null, empty or non-blank", name)
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+const (
+	draft2020      = "https://json-schema.org/draft/2020-12/schema"
+	orderSchemaURL = "mem://wb-service/order.json"
+)
+
+// fieldRule is the JSON Schema counterpart of a ValidateOrder check.
+// Required fields are the ones ValidateOrder rejects when left at their
+// zero value, so a missing property fails the same way in both.
+type fieldRule struct {
+	required bool
+	keywords map[string]any
+}
+
+var (
+	notBlank    = fieldRule{required: true, keywords: map[string]any{"pattern": `\S`}}
+	positive    = fieldRule{required: true, keywords: map[string]any{"minimum": 1}}
+	nonZero     = fieldRule{required: true, keywords: map[string]any{"not": map[string]any{"const": 0}}}
+	nonNegative = fieldRule{keywords: map[string]any{"minimum": 0}}
+	optional    = fieldRule{keywords: map[string]any{"pattern": `^$|\S`}}
+)
+
+func matches(re *regexp.Regexp) fieldRule {
+	return fieldRule{required: true, keywords: map[string]any{"pattern": re.String()}}
+}
+
+// notBlankMatching is for patterns that on their own accept whitespace-only
+// values, which ValidateOrder rejects separately.
+func notBlankMatching(re *regexp.Regexp) fieldRule {
+	return fieldRule{required: true, keywords: map[string]any{
+		"allOf": []any{
+			map[string]any{"pattern": `\S`},
+			map[string]any{"pattern": re.String()},
+		},
+	}}
+}
+
+// schemaRules mirrors the checks in ValidateOrder, keyed by model type and
+// JSON field name. Keep the two in sync when changing either.
+var schemaRules = map[reflect.Type]map[string]fieldRule{
+	reflect.TypeOf(models.Order{}): {
+		"order_uid":          notBlank,
+		"track_number":       notBlank,
+		"entry":              notBlank,
+		"locale":             notBlank,
+		"internal_signature": optional,
+		"customer_id":        notBlank,
+		"delivery_service":   notBlank,
+		"shardkey":           notBlank,
+		"sm_id":              positive,
+		"oof_shard":          notBlank,
+		"delivery":           {required: true},
+		"payment":            {required: true},
+		"items":              {required: true, keywords: map[string]any{"minItems": 1}},
+	},
+	reflect.TypeOf(models.Delivery{}): {
+		"name":    notBlank,
+		"phone":   matches(phoneRegex),
+		"zip":     matches(zipRegex),
+		"city":    notBlank,
+		"address": notBlank,
+		"region":  notBlank,
+		"email":   matches(emailRegex),
+	},
+	reflect.TypeOf(models.Payment{}): {
+		"transaction":   notBlank,
+		"request_id":    optional,
+		"currency":      matches(currencyRegex),
+		"provider":      matches(providerRegex),
+		"amount":        positive,
+		"delivery_cost": nonNegative,
+		"goods_total":   nonNegative,
+		"custom_fee":    nonNegative,
+		"payment_dt": {required: true, keywords: map[string]any{
+			"minimum":     1,
+			"description": "Unix timestamp; ValidateOrder also rejects values more than 24h in the future",
+		}},
+		"bank": notBlankMatching(bankRegex),
+	},
+	reflect.TypeOf(models.Item{}): {
+		"order_uid":    notBlank,
+		"chrt_id":      nonZero,
+		"track_number": notBlank,
+		"price":        positive,
+		"rid":          notBlank,
+		"name":         notBlank,
+		"sale":         nonNegative,
+		"size":         notBlank,
+		"total_price":  positive,
+		"nm_id":        nonZero,
+		"brand":        notBlank,
+		"status":       positive,
+	},
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// OrderSchema returns the JSON Schema (draft 2020-12) of the order payload,
+// generated from models.Order and the rules enforced by ValidateOrder.
+func OrderSchema() map[string]any {
+	schema := schemaFor(reflect.TypeOf(models.Order{}))
+	schema["$schema"] = draft2020
+	schema["title"] = "Order"
+	return schema
+}
+
+// OrderSchemaJSON is OrderSchema rendered as indented JSON.
+var OrderSchemaJSON = sync.OnceValue(func() []byte {
+	data, err := json.MarshalIndent(OrderSchema(), "", "  ")
+	if err != nil {
+		panic("failed to marshal order schema: " + err.Error())
+	}
+	return data
+})
+
+func schemaFor(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		s := schemaFor(t.Elem())
+		s["type"] = []any{s["type"], "null"}
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	rules := schemaRules[t]
+	props := make(map[string]any, t.NumField())
+	required := []any{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := schemaFor(f.Type)
+		rule := rules[name]
+		for k, v := range rule.keywords {
+			prop[k] = v
+		}
+		if rule.required {
+			required = append(required, name)
+		}
+		props[name] = prop
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+// SchemaError is a single schema violation. InstancePath is a JSON pointer
+// into the payload (/items/0/price) and SchemaPath points at the keyword
+// that failed (#/properties/items/items/properties/price/minimum).
+type SchemaError struct {
+	InstancePath string
+	SchemaPath   string
+	Msg          string
+}
+
+func (e *SchemaError) Error() string {
+	path := e.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s (schema %s)", path, e.Msg, e.SchemaPath)
+}
+
+// SchemaErrors lists every violation found in a payload.
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, se := range e {
+		msgs[i] = se.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var compiledOrderSchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(OrderSchemaJSON()))
+	if err != nil {
+		return nil, err
+	}
+
+	c := jsonschema.NewCompiler()
+	c.AssertFormat()
+	if err := c.AddResource(orderSchemaURL, doc); err != nil {
+		return nil, err
+	}
+	return c.Compile(orderSchemaURL)
+})
+
+var schemaPrinter = message.NewPrinter(language.English)
+
+// ValidateOrderJSON checks a raw order payload against OrderSchema. It
+// returns SchemaErrors for violations and a plain error for malformed JSON.
+func ValidateOrderJSON(data []byte) error {
+	schema, err := compiledOrderSchema()
+	if err != nil {
+		return fmt.Errorf("compile order schema: %w", err)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("malformed JSON: %w", err)
+	}
+
+	err = schema.Validate(doc)
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+
+	var errs SchemaErrors
+	collectSchemaErrors(verr, &errs)
+	slices.SortStableFunc(errs, func(a, b *SchemaError) int {
+		return strings.Compare(a.InstancePath, b.InstancePath)
+	})
+	return errs
+}
+
+// collectSchemaErrors keeps only the leaves of the error tree; the inner
+// nodes just say that a subschema failed.
+func collectSchemaErrors(e *jsonschema.ValidationError, errs *SchemaErrors) {
+	if len(e.Causes) > 0 {
+		for _, cause := range e.Causes {
+			collectSchemaErrors(cause, errs)
+		}
+		return
+	}
+
+	_, fragment, _ := strings.Cut(e.SchemaURL, "#")
+	schemaPath := "#" + fragment
+	kw := e.ErrorKind.KeywordPath()
+	if _, ok := e.ErrorKind.(*kind.Not); ok {
+		// The library reports "not" failures at the parent schema.
+		kw = []string{"not"}
+	}
+	if len(kw) > 0 {
+		schemaPath += "/" + strings.Join(kw, "/")
+	}
+
+	instancePath := ""
+	if len(e.InstanceLocation) > 0 {
+		instancePath = "/" + strings.Join(e.InstanceLocation, "/")
+	}
+
+	*errs = append(*errs, &SchemaError{
+		InstancePath: instancePath,
+		SchemaPath:   schemaPath,
+		Msg:          e.ErrorKind.LocalizedString(schemaPrinter),
+	})
+}
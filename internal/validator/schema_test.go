@@ -0,0 +1,153 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sonni-a/wb-service/internal/models"
+)
+
+func schemaTestOrder() models.Order {
+	uid := "b563feb7b2b84b6test"
+	return models.Order{
+		OrderUID:        uid,
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		ShardKey:        "9",
+		SmID:            99,
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		OofShard:        "1",
+		Delivery: models.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: models.Payment{
+			Transaction:  uid,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []models.Item{{
+			OrderUID:    uid,
+			ChrtID:      9934930,
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       453,
+			RID:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Size:        "0",
+			TotalPrice:  317,
+			NmID:        2389212,
+			Brand:       "Vivienne Sabo",
+			Status:      202,
+		}},
+	}
+}
+
+// Every mutation must be rejected by both ValidateOrder and the schema, at
+// the given schema path.
+func TestOrderSchema_MatchesValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		mutate     func(o *models.Order)
+		schemaPath string
+	}{
+		{"blank order_uid", func(o *models.Order) { o.OrderUID = "  " }, "#/properties/order_uid/pattern"},
+		{"zero sm_id", func(o *models.Order) { o.SmID = 0 }, "#/properties/sm_id/minimum"},
+		{"bad email", func(o *models.Order) { o.Delivery.Email = "nope" }, "#/properties/delivery/properties/email/pattern"},
+		{"bad currency", func(o *models.Order) { o.Payment.Currency = "usd" }, "#/properties/payment/properties/currency/pattern"},
+		{"blank bank", func(o *models.Order) { o.Payment.Bank = "   " }, "#/properties/payment/properties/bank/allOf/0/pattern"},
+		{"negative fee", func(o *models.Order) { o.Payment.CustomFee = -1 }, "#/properties/payment/properties/custom_fee/minimum"},
+		{"blank internal_signature", func(o *models.Order) { o.InternalSignature = ptr(" ") }, "#/properties/internal_signature/pattern"},
+		{"blank request_id", func(o *models.Order) { o.Payment.RequestID = ptr("\t") }, "#/properties/payment/properties/request_id/pattern"},
+		{"no items", func(o *models.Order) { o.Items = []models.Item{} }, "#/properties/items/minItems"},
+		{"zero price", func(o *models.Order) { o.Items[0].Price = 0 }, "#/properties/items/items/properties/price/minimum"},
+		{"zero nm_id", func(o *models.Order) { o.Items[0].NmID = 0 }, "#/properties/items/items/properties/nm_id/not"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := schemaTestOrder()
+			tt.mutate(&order)
+
+			if err := ValidateOrder(&order); err == nil {
+				t.Fatalf("ValidateOrder accepted the order")
+			}
+
+			data, _ := json.Marshal(order)
+			err := ValidateOrderJSON(data)
+
+			var errs SchemaErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected SchemaErrors, got %v", err)
+			}
+			if len(errs) != 1 || errs[0].SchemaPath != tt.schemaPath {
+				t.Fatalf("expected a single error at %s, got %v", tt.schemaPath, err)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
+
+// nil and "" are distinct, valid values for the nullable fields.
+func TestValidateOrder_NullableFields(t *testing.T) {
+	for _, v := range []*string{nil, ptr(""), ptr("sig")} {
+		order := schemaTestOrder()
+		order.InternalSignature = v
+		order.Payment.RequestID = v
+		data, _ := json.Marshal(order)
+
+		if err := ValidateOrder(&order); err != nil {
+			t.Errorf("ValidateOrder rejected %s: %v", data, err)
+		}
+		if err := ValidateOrderJSON(data); err != nil {
+			t.Errorf("schema rejected %s: %v", data, err)
+		}
+	}
+}
+
+func TestValidateOrderJSON_TypesAndMissingFields(t *testing.T) {
+	err := ValidateOrderJSON([]byte(`{"order_uid":"x","sm_id":"7","internal_signature":null,"date_created":"yesterday"}`))
+
+	var errs SchemaErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected SchemaErrors, got %v", err)
+	}
+
+	msg := err.Error()
+	for _, want := range []string{
+		"/: missing properties",
+		"/date_created: ",
+		"/sm_id: got string, want integer (schema #/properties/sm_id/type)",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in %q", want, msg)
+		}
+	}
+	if strings.Contains(msg, "internal_signature") {
+		t.Errorf("internal_signature is nullable, got %q", msg)
+	}
+}
+
+func TestValidateOrderJSON_Malformed(t *testing.T) {
+	err := ValidateOrderJSON([]byte(`{"order_uid":`))
+	var errs SchemaErrors
+	if err == nil || errors.As(err, &errs) {
+		t.Fatalf("expected a plain syntax error, got %v", err)
+	}
+}
@@ -8,9 +8,15 @@ function fetchOrder() {
     spinner.style.display = "block";
     result.innerHTML = "";
 
-    fetch(`/order/${id}`)
+    const apiKey = document.getElementById("apiKey").value.trim();
+    const headers = apiKey ? { "X-API-Key": apiKey } : {};
+
+    fetch(`/order/${encodeURIComponent(id)}`, { headers })
         .then(res => {
             spinner.style.display = "none";
+            if (res.status === 401) throw new Error("Invalid or missing API key");
+            if (res.status === 403) throw new Error("API key is not allowed to read orders");
+            if (res.status === 429) throw new Error(`Too many requests, retry in ${res.headers.get("Retry-After") || "a few"} s`);
             if (!res.ok) throw new Error("Order not found");
             return res.json();
         })
@@ -22,15 +22,11 @@ func RegisterRoutes(mux *http.ServeMux) {
 	}
 	mux.Handle("/js/", http.StripPrefix("/js/", http.FileServer(http.FS(jsFS))))
 
-	mux.HandleFunc("/", serveIndex)
+	// Only the root itself: a catch-all "/" would match every unknown URL.
+	mux.HandleFunc("/{$}", serveIndex)
 }
 
 func serveIndex(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
-		return
-	}
-
 	data, err := staticFS.ReadFile("index.html")
 	if err != nil {
 		http.Error(w, "failed to load page", http.StatusInternalServerError)
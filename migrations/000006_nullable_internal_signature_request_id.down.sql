@@ -0,0 +1,4 @@
+UPDATE orders SET internal_signature = '' WHERE internal_signature IS NULL;
+ALTER TABLE orders ALTER COLUMN internal_signature SET NOT NULL;
+UPDATE payment SET request_id = '' WHERE request_id IS NULL;
+ALTER TABLE payment ALTER COLUMN request_id SET NOT NULL;
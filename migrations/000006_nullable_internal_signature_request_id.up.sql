@@ -0,0 +1,2 @@
+ALTER TABLE orders ALTER COLUMN internal_signature DROP NOT NULL;
+ALTER TABLE payment ALTER COLUMN request_id DROP NOT NULL;
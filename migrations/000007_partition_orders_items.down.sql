@@ -0,0 +1,64 @@
+-- Archived partitions (schema archive) are left untouched.
+ALTER TABLE items RENAME TO items_partitioned;
+ALTER TABLE orders RENAME TO orders_partitioned;
+ALTER INDEX idx_items_order_uid RENAME TO idx_items_partitioned_order_uid;
+ALTER TABLE items_partitioned RENAME CONSTRAINT items_pkey TO items_partitioned_pkey;
+ALTER TABLE orders_partitioned RENAME CONSTRAINT orders_pkey TO orders_partitioned_pkey;
+
+CREATE TABLE orders (
+    order_uid UUID PRIMARY KEY,
+    track_number VARCHAR NOT NULL,
+    entry VARCHAR NOT NULL,
+    locale VARCHAR NOT NULL,
+    internal_signature VARCHAR,
+    customer_id VARCHAR NOT NULL,
+    delivery_service VARCHAR NOT NULL,
+    shardkey VARCHAR NOT NULL,
+    sm_id INTEGER NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    oof_shard VARCHAR NOT NULL
+);
+
+CREATE TABLE items (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid UUID REFERENCES orders(order_uid) ON DELETE CASCADE,
+    chrt_id BIGINT NOT NULL,
+    track_number VARCHAR NOT NULL,
+    price INTEGER NOT NULL,
+    rid VARCHAR NOT NULL,
+    name VARCHAR NOT NULL,
+    sale INTEGER NOT NULL,
+    size VARCHAR NOT NULL,
+    total_price INTEGER NOT NULL,
+    nm_id BIGINT NOT NULL,
+    brand VARCHAR NOT NULL,
+    status INTEGER NOT NULL
+);
+
+CREATE INDEX idx_items_order_uid ON items(order_uid);
+
+INSERT INTO orders
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
+       delivery_service, shardkey, sm_id, date_created, oof_shard
+FROM orders_partitioned;
+
+INSERT INTO items (id, order_uid, chrt_id, track_number, price, rid, name,
+                   sale, size, total_price, nm_id, brand, status)
+SELECT id, order_uid, chrt_id, track_number, price, rid, name,
+       sale, size, total_price, nm_id, brand, status
+FROM items_partitioned;
+
+SELECT setval(pg_get_serial_sequence('items', 'id'), COALESCE((SELECT max(id) FROM items), 0) + 1, false);
+
+DROP TABLE items_partitioned;
+DROP TABLE orders_partitioned;
+
+-- Delivery and payment rows of orders that were archived or dropped by the
+-- partition manager have no order any more.
+DELETE FROM delivery d WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = d.order_uid);
+DELETE FROM payment p WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.order_uid = p.order_uid);
+
+ALTER TABLE delivery ADD CONSTRAINT delivery_order_uid_fkey
+    FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+ALTER TABLE payment ADD CONSTRAINT payment_order_uid_fkey
+    FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
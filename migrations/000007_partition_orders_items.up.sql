@@ -0,0 +1,89 @@
+-- Partition orders and items by date_created month. delivery and payment stay
+-- unpartitioned; their primary keys still guarantee that an order_uid is
+-- stored only once, but they can no longer reference orders, whose primary
+-- key now has to include the partition key.
+ALTER TABLE delivery DROP CONSTRAINT delivery_order_uid_fkey;
+ALTER TABLE payment DROP CONSTRAINT payment_order_uid_fkey;
+
+ALTER TABLE items RENAME TO items_legacy;
+ALTER TABLE items_legacy RENAME CONSTRAINT items_pkey TO items_legacy_pkey;
+ALTER INDEX idx_items_order_uid RENAME TO idx_items_legacy_order_uid;
+ALTER TABLE orders RENAME TO orders_legacy;
+ALTER TABLE orders_legacy RENAME CONSTRAINT orders_pkey TO orders_legacy_pkey;
+
+CREATE TABLE orders (
+    order_uid UUID NOT NULL,
+    track_number VARCHAR NOT NULL,
+    entry VARCHAR NOT NULL,
+    locale VARCHAR NOT NULL,
+    internal_signature VARCHAR,
+    customer_id VARCHAR NOT NULL,
+    delivery_service VARCHAR NOT NULL,
+    shardkey VARCHAR NOT NULL,
+    sm_id INTEGER NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    oof_shard VARCHAR NOT NULL,
+    PRIMARY KEY (order_uid, date_created)
+) PARTITION BY RANGE (date_created);
+
+CREATE TABLE items (
+    id BIGSERIAL NOT NULL,
+    order_uid UUID NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    chrt_id BIGINT NOT NULL,
+    track_number VARCHAR NOT NULL,
+    price INTEGER NOT NULL,
+    rid VARCHAR NOT NULL,
+    name VARCHAR NOT NULL,
+    sale INTEGER NOT NULL,
+    size VARCHAR NOT NULL,
+    total_price INTEGER NOT NULL,
+    nm_id BIGINT NOT NULL,
+    brand VARCHAR NOT NULL,
+    status INTEGER NOT NULL,
+    PRIMARY KEY (id, date_created),
+    CONSTRAINT items_order_fkey FOREIGN KEY (order_uid, date_created)
+        REFERENCES orders (order_uid, date_created) ON DELETE CASCADE
+) PARTITION BY RANGE (date_created);
+
+CREATE INDEX idx_items_order_uid ON items (order_uid, date_created);
+
+-- Orders with dates outside every monthly partition land here instead of
+-- failing; the partition manager moves them out once their month exists.
+CREATE TABLE orders_default PARTITION OF orders DEFAULT;
+CREATE TABLE items_default PARTITION OF items DEFAULT;
+
+-- Monthly partitions for the existing data and the next three months.
+DO $$
+DECLARE
+    m DATE;
+BEGIN
+    FOR m IN
+        SELECT generate_series(
+            LEAST(date_trunc('month', (SELECT min(date_created) FROM orders_legacy)), date_trunc('month', now())),
+            date_trunc('month', now()) + INTERVAL '3 months',
+            INTERVAL '1 month')::date
+    LOOP
+        EXECUTE format('CREATE TABLE %I PARTITION OF orders FOR VALUES FROM (%L) TO (%L)',
+            'orders_' || to_char(m, 'YYYY_MM'), m, m + INTERVAL '1 month');
+        EXECUTE format('CREATE TABLE %I PARTITION OF items FOR VALUES FROM (%L) TO (%L)',
+            'items_' || to_char(m, 'YYYY_MM'), m, m + INTERVAL '1 month');
+    END LOOP;
+END $$;
+
+INSERT INTO orders
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
+       delivery_service, shardkey, sm_id, date_created, oof_shard
+FROM orders_legacy;
+
+INSERT INTO items (id, order_uid, date_created, chrt_id, track_number, price, rid, name,
+                   sale, size, total_price, nm_id, brand, status)
+SELECT i.id, i.order_uid, o.date_created, i.chrt_id, i.track_number, i.price, i.rid, i.name,
+       i.sale, i.size, i.total_price, i.nm_id, i.brand, i.status
+FROM items_legacy i
+JOIN orders_legacy o ON o.order_uid = i.order_uid;
+
+SELECT setval(pg_get_serial_sequence('items', 'id'), COALESCE((SELECT max(id) FROM items), 0) + 1, false);
+
+DROP TABLE items_legacy;
+DROP TABLE orders_legacy;
@@ -0,0 +1,14 @@
+-- Append-only record of changes to stored data. Entries must not hold
+-- personal data in clear, so that erasing a customer leaves none behind.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor VARCHAR NOT NULL,
+    source VARCHAR NOT NULL,
+    action VARCHAR NOT NULL,
+    order_uid UUID,
+    diff JSONB
+);
+
+CREATE INDEX idx_audit_log_order_uid ON audit_log (order_uid);
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
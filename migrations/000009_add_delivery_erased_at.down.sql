@@ -0,0 +1 @@
+ALTER TABLE delivery DROP COLUMN erased_at;
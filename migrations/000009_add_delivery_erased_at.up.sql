@@ -0,0 +1,3 @@
+-- Set when the delivery PII of an order has been erased on the customer's
+-- request; the order, payment and items are kept.
+ALTER TABLE delivery ADD COLUMN erased_at TIMESTAMPTZ;
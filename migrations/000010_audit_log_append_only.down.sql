@@ -0,0 +1,4 @@
+DROP INDEX idx_audit_log_action;
+DROP TRIGGER audit_log_no_truncate ON audit_log;
+DROP TRIGGER audit_log_no_update_delete ON audit_log;
+DROP FUNCTION audit_log_reject_change();